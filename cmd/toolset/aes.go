@@ -0,0 +1,399 @@
+package main
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/nonex-code/toolset/gaes"
+	"golang.org/x/term"
+)
+
+const aesUsage = `用法：
+  toolset aes encrypt [参数]
+  toolset aes decrypt [参数]
+
+格式（-format）：
+  cbc       gaes.Encrypt / gaes.Decrypt，AES-CBC 随机 IV（默认）
+  legacy    gaes.DecryptLegacy，旧版本以 key 作 IV 的密文，仅支持解密
+  gcm       gaes.SealGCM / gaes.OpenGCM，可用 -ad 指定附加数据
+  stream    gaes.NewEncryptWriter / gaes.NewDecryptReader，适合大文件
+  password  gaes.EncryptWithPassword / gaes.DecryptWithPassword，密钥作为口令
+
+密钥来源依次为 -key-file、-key-env，都未指定时从终端读取
+
+参数：
+`
+
+// aesOptions aes 子命令参数
+type aesOptions struct {
+	in          string
+	out         string
+	format      string
+	encoding    string
+	keyFile     string
+	keyEnv      string
+	keyEncoding string
+	ad          string
+}
+
+func runAES(args []string, stdin io.Reader, stdout, stderr io.Writer) int {
+	fs := flag.NewFlagSet("aes", flag.ContinueOnError)
+	fs.SetOutput(stderr)
+	fs.Usage = func() {
+		fmt.Fprint(stderr, aesUsage)
+		fs.PrintDefaults()
+	}
+	if len(args) == 0 || (args[0] != "encrypt" && args[0] != "decrypt") {
+		if len(args) > 0 && (args[0] == "-h" || args[0] == "-help" || args[0] == "--help") {
+			fs.SetOutput(stdout)
+			fmt.Fprint(stdout, aesUsage)
+			fs.PrintDefaults()
+			return 0
+		}
+		fs.Usage()
+		return 2
+	}
+	encrypt := args[0] == "encrypt"
+	var opts aesOptions
+	fs.StringVar(&opts.in, "in", "-", "输入文件，- 表示标准输入")
+	fs.StringVar(&opts.out, "out", "-", "输出文件，- 表示标准输出")
+	fs.StringVar(&opts.format, "format", "cbc", "密文格式：cbc、legacy、gcm、stream、password")
+	fs.StringVar(&opts.encoding, "encoding", "raw", "密文编码：raw、hex、base64、base64url（加密时为输出编码，解密时为输入编码）")
+	fs.StringVar(&opts.keyFile, "key-file", "", "从文件读取密钥")
+	fs.StringVar(&opts.keyEnv, "key-env", "", "从环境变量读取密钥")
+	fs.StringVar(&opts.keyEncoding, "key-encoding", "raw", "密钥编码：raw、hex、base64")
+	fs.StringVar(&opts.ad, "ad", "", "gcm 格式的附加认证数据")
+	if err := fs.Parse(args[1:]); err != nil {
+		if errors.Is(err, flag.ErrHelp) {
+			return 0
+		}
+		return 2
+	}
+	if fs.NArg() > 0 {
+		fmt.Fprintf(stderr, "多余的参数: %s\n", strings.Join(fs.Args(), " "))
+		return 2
+	}
+	if err := aesCommand(encrypt, &opts, stdin, stdout, stderr); err != nil {
+		fmt.Fprintln(stderr, "错误:", err)
+		return 1
+	}
+	return 0
+}
+
+// aesCommand 读取密钥和输入，加解密后写出
+func aesCommand(encrypt bool, opts *aesOptions, stdin io.Reader, stdout, stderr io.Writer) error {
+	switch opts.format {
+	case "cbc", "gcm", "stream", "password":
+	case "legacy":
+		if encrypt {
+			return errors.New("legacy 格式仅支持解密")
+		}
+	default:
+		return fmt.Errorf("未知格式 %q", opts.format)
+	}
+	if err := checkSameFile(opts.in, opts.out); err != nil {
+		return err
+	}
+	key, err := readKey(opts, stderr)
+	if err != nil {
+		return err
+	}
+	in, closeIn, err := openInput(opts.in, stdin)
+	if err != nil {
+		return err
+	}
+	defer closeIn()
+	out, closeOut, err := openOutput(opts.out, stdout)
+	if err != nil {
+		return err
+	}
+	if opts.format == "stream" {
+		err = aesStream(encrypt, key, opts.encoding, in, out)
+	} else {
+		err = aesBytes(encrypt, key, opts, in, out)
+	}
+	if cerr := closeOut(err == nil); err == nil {
+		err = cerr
+	}
+	return err
+}
+
+// aesBytes 整体读入后加解密
+func aesBytes(encrypt bool, key []byte, opts *aesOptions, in io.Reader, out io.Writer) error {
+	data, err := io.ReadAll(in)
+	if err != nil {
+		return err
+	}
+	var result []byte
+	if encrypt {
+		switch opts.format {
+		case "cbc":
+			result, err = gaes.Encrypt(data, key)
+		case "gcm":
+			result, err = gaes.SealGCM(data, key, []byte(opts.ad))
+		case "password":
+			result, err = gaes.EncryptWithPassword(data, key, nil)
+		}
+		if err != nil {
+			return err
+		}
+		result, err = encode(opts.encoding, result)
+		if err != nil {
+			return err
+		}
+	} else {
+		if data, err = decode(opts.encoding, data); err != nil {
+			return err
+		}
+		switch opts.format {
+		case "cbc":
+			result, err = gaes.Decrypt(data, key)
+		case "legacy":
+			result, err = gaes.DecryptLegacy(data, key)
+		case "gcm":
+			result, err = gaes.OpenGCM(data, key, []byte(opts.ad))
+		case "password":
+			result, err = gaes.DecryptWithPassword(data, key)
+		}
+		if err != nil {
+			return err
+		}
+	}
+	_, err = out.Write(result)
+	return err
+}
+
+// aesStream 流式加解密，密文编码也以流的方式处理
+func aesStream(encrypt bool, key []byte, encoding string, in io.Reader, out io.Writer) error {
+	if encrypt {
+		encoded, err := encodeWriter(encoding, out)
+		if err != nil {
+			return err
+		}
+		w, err := gaes.NewEncryptWriter(encoded, key)
+		if err != nil {
+			return err
+		}
+		if _, err := io.Copy(w, in); err != nil {
+			return err
+		}
+		if err := w.Close(); err != nil {
+			return err
+		}
+		if err := encoded.Close(); err != nil {
+			return err
+		}
+		// 与整体加密一致，文本编码末尾加换行
+		if encoding != "raw" {
+			_, err = io.WriteString(out, "\n")
+		}
+		return err
+	}
+	decoded, err := decodeReader(encoding, in)
+	if err != nil {
+		return err
+	}
+	r, err := gaes.NewDecryptReader(decoded, key)
+	if err != nil {
+		return err
+	}
+	_, err = io.Copy(out, r)
+	return err
+}
+
+// readKey 按 -key-file、-key-env、终端输入的顺序读取密钥
+func readKey(opts *aesOptions, stderr io.Writer) ([]byte, error) {
+	var raw []byte
+	switch {
+	case opts.keyFile != "":
+		b, err := os.ReadFile(opts.keyFile)
+		if err != nil {
+			return nil, err
+		}
+		raw = bytes.TrimRight(b, "\r\n")
+	case opts.keyEnv != "":
+		v, ok := os.LookupEnv(opts.keyEnv)
+		if !ok {
+			return nil, fmt.Errorf("环境变量 %s 未设置", opts.keyEnv)
+		}
+		raw = []byte(v)
+	default:
+		tty, err := os.Open("/dev/tty")
+		if err != nil {
+			return nil, errors.New("无法打开终端读取密钥，请使用 -key-file 或 -key-env")
+		}
+		defer tty.Close()
+		fmt.Fprint(stderr, "请输入密钥: ")
+		raw, err = term.ReadPassword(int(tty.Fd()))
+		fmt.Fprintln(stderr)
+		if err != nil {
+			return nil, err
+		}
+	}
+	if len(raw) == 0 {
+		return nil, errors.New("密钥为空")
+	}
+	switch opts.keyEncoding {
+	case "raw":
+		return raw, nil
+	case "hex":
+		return hex.DecodeString(strings.TrimSpace(string(raw)))
+	case "base64":
+		return base64.StdEncoding.DecodeString(strings.TrimSpace(string(raw)))
+	default:
+		return nil, fmt.Errorf("未知密钥编码 %q", opts.keyEncoding)
+	}
+}
+
+func openInput(path string, stdin io.Reader) (io.Reader, func() error, error) {
+	if path == "-" {
+		return stdin, func() error { return nil }, nil
+	}
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, nil, err
+	}
+	return f, f.Close, nil
+}
+
+// openOutput 打开输出，文件先写入同目录下的临时文件，commit 为 true 时才重命名为目标文件，
+// 密钥错误或输入损坏时不会截断或留下不完整的输出
+func openOutput(path string, stdout io.Writer) (io.Writer, func(commit bool) error, error) {
+	if path == "-" {
+		return stdout, func(bool) error { return nil }, nil
+	}
+	f, err := os.CreateTemp(filepath.Dir(path), "."+filepath.Base(path)+".tmp*")
+	if err != nil {
+		return nil, nil, err
+	}
+	closeOut := func(commit bool) error {
+		err := f.Close()
+		if commit && err == nil {
+			err = os.Rename(f.Name(), path)
+		}
+		if !commit || err != nil {
+			os.Remove(f.Name())
+		}
+		return err
+	}
+	return f, closeOut, nil
+}
+
+// checkSameFile 输入和输出不能是同一个文件
+func checkSameFile(in, out string) error {
+	if in == "-" || out == "-" {
+		return nil
+	}
+	if filepath.Clean(in) == filepath.Clean(out) {
+		return errors.New("输入和输出不能是同一个文件")
+	}
+	inInfo, err := os.Stat(in)
+	if err != nil {
+		return nil
+	}
+	if outInfo, err := os.Stat(out); err == nil && os.SameFile(inInfo, outInfo) {
+		return errors.New("输入和输出不能是同一个文件")
+	}
+	return nil
+}
+
+// encode 按密文编码输出，文本编码末尾加换行
+func encode(encoding string, data []byte) ([]byte, error) {
+	switch encoding {
+	case "raw":
+		return data, nil
+	case "hex":
+		return []byte(hex.EncodeToString(data) + "\n"), nil
+	case "base64":
+		return []byte(base64.StdEncoding.EncodeToString(data) + "\n"), nil
+	case "base64url":
+		return []byte(base64.RawURLEncoding.EncodeToString(data) + "\n"), nil
+	default:
+		return nil, fmt.Errorf("未知编码 %q", encoding)
+	}
+}
+
+// decode 按密文编码解析输入，忽略首尾空白
+func decode(encoding string, data []byte) ([]byte, error) {
+	text := strings.TrimSpace(string(data))
+	switch encoding {
+	case "raw":
+		return data, nil
+	case "hex":
+		return hex.DecodeString(text)
+	case "base64":
+		return base64.StdEncoding.DecodeString(text)
+	case "base64url":
+		return base64.RawURLEncoding.DecodeString(strings.TrimRight(text, "="))
+	default:
+		return nil, fmt.Errorf("未知编码 %q", encoding)
+	}
+}
+
+// nopWriteCloser raw 编码时 Close 不做任何事
+type nopWriteCloser struct {
+	io.Writer
+}
+
+func (nopWriteCloser) Close() error { return nil }
+
+// encodeWriter 流式编码输出
+func encodeWriter(encoding string, w io.Writer) (io.WriteCloser, error) {
+	switch encoding {
+	case "raw":
+		return nopWriteCloser{w}, nil
+	case "hex":
+		return nopWriteCloser{hex.NewEncoder(w)}, nil
+	case "base64":
+		return base64.NewEncoder(base64.StdEncoding, w), nil
+	case "base64url":
+		return base64.NewEncoder(base64.RawURLEncoding, w), nil
+	default:
+		return nil, fmt.Errorf("未知编码 %q", encoding)
+	}
+}
+
+// decodeReader 流式解析输入
+func decodeReader(encoding string, r io.Reader) (io.Reader, error) {
+	switch encoding {
+	case "raw":
+		return r, nil
+	case "hex":
+		return hex.NewDecoder(newlineFilter{r}), nil
+	case "base64":
+		return base64.NewDecoder(base64.StdEncoding, r), nil
+	case "base64url":
+		return base64.NewDecoder(base64.RawURLEncoding, r), nil
+	default:
+		return nil, fmt.Errorf("未知编码 %q", encoding)
+	}
+}
+
+// newlineFilter 过滤换行和空白，hex 解码器不会忽略它们
+type newlineFilter struct {
+	r io.Reader
+}
+
+func (f newlineFilter) Read(p []byte) (int, error) {
+	for {
+		n, err := f.r.Read(p)
+		j := 0
+		for _, b := range p[:n] {
+			if b != '\n' && b != '\r' && b != ' ' && b != '\t' {
+				p[j] = b
+				j++
+			}
+		}
+		if j > 0 || err != nil {
+			return j, err
+		}
+	}
+}
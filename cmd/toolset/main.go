@@ -0,0 +1,40 @@
+// toolset 命令行工具，目前提供 aes 加解密，输出格式与 gaes 库一致，可与服务端代码互通
+//
+// 用法：
+//
+//	toolset aes encrypt|decrypt [参数]
+package main
+
+import (
+	"fmt"
+	"io"
+	"os"
+)
+
+const usage = `用法：
+  toolset aes encrypt|decrypt [参数]    AES 加解密文件或标准输入
+
+使用 "toolset aes -h" 查看参数说明
+`
+
+func main() {
+	os.Exit(run(os.Args[1:], os.Stdin, os.Stdout, os.Stderr))
+}
+
+// run 执行命令，返回退出码
+func run(args []string, stdin io.Reader, stdout, stderr io.Writer) int {
+	if len(args) == 0 {
+		fmt.Fprint(stderr, usage)
+		return 2
+	}
+	switch args[0] {
+	case "aes":
+		return runAES(args[1:], stdin, stdout, stderr)
+	case "-h", "-help", "--help", "help":
+		fmt.Fprint(stdout, usage)
+		return 0
+	default:
+		fmt.Fprintf(stderr, "未知命令 %q\n%s", args[0], usage)
+		return 2
+	}
+}
@@ -0,0 +1,152 @@
+package main
+
+import (
+	"bytes"
+	"encoding/base64"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/nonex-code/toolset/gaes"
+)
+
+func TestAESRoundTrip(t *testing.T) {
+	t.Setenv("TOOLSET_TEST_KEY", "1111111111111111")
+	data := bytes.Repeat([]byte("123"), 50000)
+	for _, format := range []string{"cbc", "gcm", "stream", "password"} {
+		for _, encoding := range []string{"raw", "hex", "base64", "base64url"} {
+			var ed, dd, stderr bytes.Buffer
+			args := []string{"-format", format, "-encoding", encoding, "-key-env", "TOOLSET_TEST_KEY"}
+			if code := run(append([]string{"aes", "encrypt"}, args...), bytes.NewReader(data), &ed, &stderr); code != 0 {
+				t.Errorf("%s/%s 加密失败: %s", format, encoding, stderr.String())
+				continue
+			}
+			if code := run(append([]string{"aes", "decrypt"}, args...), &ed, &dd, &stderr); code != 0 {
+				t.Errorf("%s/%s 解密失败: %s", format, encoding, stderr.String())
+				continue
+			}
+			if !bytes.Equal(dd.Bytes(), data) {
+				t.Errorf("%s/%s 解密结果不一致", format, encoding)
+			}
+		}
+	}
+}
+
+// 命令行输出与库函数互通
+func TestAESLibraryCompatible(t *testing.T) {
+	dir := t.TempDir()
+	keyFile := filepath.Join(dir, "key")
+	if err := os.WriteFile(keyFile, []byte("31313131313131313131313131313131\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	key := []byte("1111111111111111")
+	ed, err := gaes.Encrypt([]byte("123"), key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	in := filepath.Join(dir, "in")
+	if err := os.WriteFile(in, []byte(base64.StdEncoding.EncodeToString(ed)), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	var stdout, stderr bytes.Buffer
+	args := []string{"aes", "decrypt", "-in", in, "-encoding", "base64", "-key-file", keyFile, "-key-encoding", "hex"}
+	if code := run(args, nil, &stdout, &stderr); code != 0 {
+		t.Fatalf("解密失败: %s", stderr.String())
+	}
+	if stdout.String() != "123" {
+		t.Errorf("解密结果不一致: %q", stdout.String())
+	}
+
+	out := filepath.Join(dir, "out")
+	args = []string{"aes", "encrypt", "-format", "gcm", "-ad", "token", "-out", out, "-key-file", keyFile, "-key-encoding", "hex"}
+	if code := run(args, strings.NewReader("456"), &stdout, &stderr); code != 0 {
+		t.Fatalf("加密失败: %s", stderr.String())
+	}
+	sealed, err := os.ReadFile(out)
+	if err != nil {
+		t.Fatal(err)
+	}
+	dd, err := gaes.OpenGCM(sealed, key, []byte("token"))
+	if err != nil || string(dd) != "456" {
+		t.Errorf("库函数解密失败: %q %v", dd, err)
+	}
+}
+
+func TestAESInvalidArgs(t *testing.T) {
+	var stdout, stderr bytes.Buffer
+	for _, args := range [][]string{
+		{},
+		{"rsa"},
+		{"aes"},
+		{"aes", "encrypt", "-format", "legacy", "-key-env", "PATH"},
+		{"aes", "encrypt", "-format", "des", "-key-env", "PATH"},
+		{"aes", "encrypt", "-key-env", "TOOLSET_TEST_KEY_NOT_SET"},
+	} {
+		if code := run(args, strings.NewReader(""), &stdout, &stderr); code == 0 {
+			t.Errorf("%v 应返回非零退出码", args)
+		}
+	}
+}
+
+// 解密失败时不能截断已有的输出文件，也不能留下临时文件
+func TestAESOutputFile(t *testing.T) {
+	t.Setenv("TOOLSET_TEST_KEY", "1111111111111111")
+	t.Setenv("TOOLSET_TEST_WRONG_KEY", "2222222222222222")
+	dir := t.TempDir()
+	in, out := filepath.Join(dir, "in"), filepath.Join(dir, "out")
+	var stdout, stderr bytes.Buffer
+	for _, format := range []string{"gcm", "stream"} {
+		if err := os.WriteFile(out, []byte("old"), 0o600); err != nil {
+			t.Fatal(err)
+		}
+		args := []string{"aes", "encrypt", "-format", format, "-out", in, "-key-env", "TOOLSET_TEST_KEY"}
+		if code := run(args, strings.NewReader("123"), &stdout, &stderr); code != 0 {
+			t.Fatalf("%s 加密失败: %s", format, stderr.String())
+		}
+		args = []string{"aes", "decrypt", "-format", format, "-in", in, "-out", out, "-key-env", "TOOLSET_TEST_WRONG_KEY"}
+		if code := run(args, nil, &stdout, &stderr); code == 0 {
+			t.Fatalf("%s 密钥错误应返回非零退出码", format)
+		}
+		if b, _ := os.ReadFile(out); string(b) != "old" {
+			t.Errorf("%s 解密失败后输出文件被修改: %q", format, b)
+		}
+		if entries, _ := os.ReadDir(dir); len(entries) != 2 {
+			t.Errorf("%s 解密失败后残留临时文件: %v", format, entries)
+		}
+		args = []string{"aes", "decrypt", "-format", format, "-in", in, "-out", out, "-key-env", "TOOLSET_TEST_KEY"}
+		if code := run(args, nil, &stdout, &stderr); code != 0 {
+			t.Fatalf("%s 解密失败: %s", format, stderr.String())
+		}
+		if b, _ := os.ReadFile(out); string(b) != "123" {
+			t.Errorf("%s 解密结果不一致: %q", format, b)
+		}
+	}
+
+	// 输入和输出为同一个文件时拒绝执行，输入保持不变
+	before, _ := os.ReadFile(in)
+	for _, same := range []string{in, filepath.Join(dir, ".", "in")} {
+		args := []string{"aes", "decrypt", "-format", "stream", "-in", in, "-out", same, "-key-env", "TOOLSET_TEST_KEY"}
+		if code := run(args, nil, &stdout, &stderr); code == 0 {
+			t.Errorf("-in %s -out %s 应返回非零退出码", in, same)
+		}
+	}
+	if after, _ := os.ReadFile(in); !bytes.Equal(before, after) {
+		t.Error("输入文件被修改")
+	}
+}
+
+// 流式加密的文本编码输出和整体加密一样以换行结尾
+func TestAESStreamTrailingNewline(t *testing.T) {
+	t.Setenv("TOOLSET_TEST_KEY", "1111111111111111")
+	for _, encoding := range []string{"hex", "base64", "base64url"} {
+		var stdout, stderr bytes.Buffer
+		args := []string{"aes", "encrypt", "-format", "stream", "-encoding", encoding, "-key-env", "TOOLSET_TEST_KEY"}
+		if code := run(args, strings.NewReader("123"), &stdout, &stderr); code != 0 {
+			t.Fatalf("%s 加密失败: %s", encoding, stderr.String())
+		}
+		if s := stdout.String(); !strings.HasSuffix(s, "\n") || strings.Count(s, "\n") != 1 {
+			t.Errorf("%s 输出应以一个换行结尾: %q", encoding, s)
+		}
+	}
+}
@@ -1,43 +1,62 @@
 package gaes
 
 import (
-	"bytes"
 	"crypto/aes"
 	"crypto/cipher"
+	"crypto/rand"
+	"crypto/subtle"
 	"errors"
+	"io"
 )
 
 //加密过程：
 //  1、处理数据，对数据进行填充，采用PKCS7（当密钥长度不够时，缺几位补几个几）的方式。
-//  2、对数据进行加密，采用AES加密方法中CBC加密模式
-//  3、对得到的加密数据，进行base64加密，得到字符串
+//  2、每次加密随机生成 16 字节 IV，采用AES加密方法中CBC加密模式
+//  3、输出格式为 版本号(1字节) || IV(16字节) || 密文
 // 解密过程相反
+//
+// 旧版本直接使用 key 的前 16 字节作为 IV 且不带版本号，相同明文总是得到相同密文，
+// 旧数据需通过 DecryptLegacy 显式解密，新数据不要再使用旧格式
 
 //16,24,32位字符串的话，分别对应AES-128，AES-192，AES-256 加密方法
 //key不能泄露
 //参考链接 https://www.jianshu.com/p/0caab60fea9f
 
-//pkcs7Padding 填充
-func pkcs7Padding(data []byte, blockSize int) []byte {
-	//判断缺少几位长度。最少1，最多 blockSize
-	padding := blockSize - len(data)%blockSize
-	//补足位数。把切片[]byte{byte(padding)}复制padding个
-	padText := bytes.Repeat([]byte{byte(padding)}, padding)
-	return append(data, padText...)
-}
+// ErrInvalidPadding 填充格式错误
+var ErrInvalidPadding = errors.New("gaes: 填充格式错误")
+
+// ErrNotBlockAligned 密文长度不是块大小的整数倍
+var ErrNotBlockAligned = errors.New("gaes: 密文长度不是块大小的整数倍")
+
+// ErrUnknownFormat 密文版本号无法识别
+var ErrUnknownFormat = errors.New("gaes: 无法识别的密文格式")
 
-//pkcs7UnPadding 填充的反向操作
-func pkcs7UnPadding(data []byte) ([]byte, error) {
+// ErrLegacyFormat 密文为旧版本的 key 作 IV 格式，需使用 DecryptLegacy 解密
+var ErrLegacyFormat = errors.New("gaes: 旧版本密文格式，请使用 DecryptLegacy")
+
+// pkcs7UnPadding 填充的反向操作，以常量时间校验全部填充字节，防止填充预言攻击
+func pkcs7UnPadding(data []byte, blockSize int) ([]byte, error) {
 	length := len(data)
-	if length == 0 {
-		return nil, errors.New("加密字符串错误！")
+	if length == 0 || length%blockSize != 0 {
+		return nil, ErrNotBlockAligned
+	}
+	//获取填充的个数，必须在 1 到 blockSize 之间
+	padding := int(data[length-1])
+	good := subtle.ConstantTimeLessOrEq(1, padding) & subtle.ConstantTimeLessOrEq(padding, blockSize)
+	//检查最后一个块，处于填充范围内的字节必须都等于填充个数，不提前退出
+	last := data[length-blockSize:]
+	for i := 1; i <= blockSize; i++ {
+		inPadding := subtle.ConstantTimeLessOrEq(i, padding)
+		equal := subtle.ConstantTimeByteEq(last[blockSize-i], byte(padding))
+		good &= equal | (inPadding ^ 1)
 	}
-	//获取填充的个数
-	unPadding := int(data[length-1])
-	return data[:(length - unPadding)], nil
+	if good != 1 {
+		return nil, ErrInvalidPadding
+	}
+	return data[:(length - padding)], nil
 }
 
-//Encrypt 加密
+// Encrypt 加密，每次调用使用随机 IV，输出 版本号 || IV || 密文
 func Encrypt(data []byte, key []byte) ([]byte, error) {
 	//创建加密实例
 	block, err := aes.NewCipher(key)
@@ -46,18 +65,27 @@ func Encrypt(data []byte, key []byte) ([]byte, error) {
 	}
 	//判断加密快的大小
 	blockSize := block.BlockSize()
-	//填充
-	encryptBytes := pkcs7Padding(data, blockSize)
-	//初始化加密数据接收切片
-	crypted := make([]byte, len(encryptBytes))
+	//填充，PKCS7.Pad 返回新的切片，不会写入调用方 data 之后的容量
+	encryptBytes, err := PKCS7.Pad(data, blockSize)
+	if err != nil {
+		return nil, err
+	}
+	//初始化加密数据接收切片，前面预留版本号和IV
+	crypted := make([]byte, 1+blockSize+len(encryptBytes))
+	crypted[0] = formatCBC
+	iv := crypted[1 : 1+blockSize]
+	//生成随机IV
+	if _, err := io.ReadFull(rand.Reader, iv); err != nil {
+		return nil, err
+	}
 	//使用cbc加密模式
-	blockMode := cipher.NewCBCEncrypter(block, key[:blockSize])
+	blockMode := cipher.NewCBCEncrypter(block, iv)
 	//执行加密
-	blockMode.CryptBlocks(crypted, encryptBytes)
+	blockMode.CryptBlocks(crypted[1+blockSize:], encryptBytes)
 	return crypted, nil
 }
 
-//Decrypt 解密
+// Decrypt 解密 Encrypt 的输出，旧格式密文返回 ErrLegacyFormat
 func Decrypt(data []byte, key []byte) ([]byte, error) {
 	//创建实例
 	block, err := aes.NewCipher(key)
@@ -66,16 +94,51 @@ func Decrypt(data []byte, key []byte) ([]byte, error) {
 	}
 	//获取块的大小
 	blockSize := block.BlockSize()
+	if len(data) == 0 {
+		return nil, ErrCiphertextTooShort
+	}
+	//旧格式密文长度总是块大小的整数倍
+	if len(data)%blockSize == 0 {
+		return nil, ErrLegacyFormat
+	}
+	if data[0] != formatCBC {
+		return nil, ErrUnknownFormat
+	}
+	if len(data) < 1+2*blockSize {
+		return nil, ErrCiphertextTooShort
+	}
+	if (len(data)-1)%blockSize != 0 {
+		return nil, ErrNotBlockAligned
+	}
+	return decryptCBC(block, data[1:1+blockSize], data[1+blockSize:])
+}
+
+// DecryptLegacy 兼容模式，解密旧版本以 key 作为 IV 的密文
+func DecryptLegacy(data []byte, key []byte) ([]byte, error) {
+	//创建实例
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	//获取块的大小
+	blockSize := block.BlockSize()
+	if len(data) == 0 {
+		return nil, ErrCiphertextTooShort
+	}
+	if len(data)%blockSize != 0 {
+		return nil, ErrNotBlockAligned
+	}
+	return decryptCBC(block, key[:blockSize], data)
+}
+
+// decryptCBC 使用cbc解密并去除填充
+func decryptCBC(block cipher.Block, iv, data []byte) ([]byte, error) {
 	//使用cbc
-	blockMode := cipher.NewCBCDecrypter(block, key[:blockSize])
+	blockMode := cipher.NewCBCDecrypter(block, iv)
 	//初始化解密数据接收切片
 	crypted := make([]byte, len(data))
 	//执行解密
 	blockMode.CryptBlocks(crypted, data)
 	//去除填充
-	crypted, err = pkcs7UnPadding(crypted)
-	if err != nil {
-		return nil, err
-	}
-	return crypted, nil
+	return pkcs7UnPadding(crypted, block.BlockSize())
 }
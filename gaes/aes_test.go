@@ -1,6 +1,10 @@
 package gaes
 
 import (
+	"bytes"
+	"crypto/aes"
+	"crypto/cipher"
+	"errors"
 	"testing"
 )
 
@@ -19,3 +23,159 @@ func TestGaes(t *testing.T) {
 	}
 	t.Log(string(dd))
 }
+
+func TestEncryptRandomIV(t *testing.T) {
+	data := []byte("123")
+	key := []byte("1111111111111111")
+	a, err := Encrypt(data, key)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	b, err := Encrypt(data, key)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	if bytes.Equal(a, b) {
+		t.Error("相同明文两次加密结果不应相同")
+	}
+	if a[0] != formatCBC || bytes.Equal(a[1:17], key) {
+		t.Errorf("密文格式错误: %x", a)
+	}
+}
+
+// legacyEncrypt 旧版本以 key 作为 IV 的加密方式
+func legacyEncrypt(data, key []byte) []byte {
+	block, _ := aes.NewCipher(key)
+	padded, _ := PKCS7.Pad(data, block.BlockSize())
+	crypted := make([]byte, len(padded))
+	cipher.NewCBCEncrypter(block, key[:block.BlockSize()]).CryptBlocks(crypted, padded)
+	return crypted
+}
+
+// 加密子切片时不能改写调用方缓冲区中子切片之后的数据
+func TestEncryptSubslice(t *testing.T) {
+	key := []byte("1111111111111111")
+	buf := []byte("abcdefghijklmnopqrstuvwxyz")
+	want := append([]byte(nil), buf...)
+	ed, err := Encrypt(buf[:3], key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(buf, want) {
+		t.Fatalf("缓冲区被改写: %q", buf)
+	}
+	if dd, err := Decrypt(ed, key); err != nil || string(dd) != "abc" {
+		t.Fatalf("%q %v", dd, err)
+	}
+}
+
+func TestDecryptLegacy(t *testing.T) {
+	data := []byte("stored before v1")
+	key := []byte("1111111111111111")
+	old := legacyEncrypt(data, key)
+	if _, err := Decrypt(old, key); !errors.Is(err, ErrLegacyFormat) {
+		t.Errorf("旧格式应返回 ErrLegacyFormat, got %v", err)
+	}
+	dd, err := DecryptLegacy(old, key)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	if !bytes.Equal(dd, data) {
+		t.Errorf("解密结果不一致: %q", dd)
+	}
+}
+
+func TestPKCS7UnPadding(t *testing.T) {
+	block := bytes.Repeat([]byte{'a'}, 16)
+	withTail := func(tail ...byte) []byte {
+		return append(append([]byte(nil), block[:16-len(tail)]...), tail...)
+	}
+	cases := []struct {
+		name string
+		data []byte
+		want []byte
+		err  error
+	}{
+		{"一字节填充", withTail(1), block[:15], nil},
+		{"三字节填充", withTail(3, 3, 3), block[:13], nil},
+		{"整块填充", bytes.Repeat([]byte{16}, 16), []byte{}, nil},
+		{"空数据", nil, nil, ErrNotBlockAligned},
+		{"未对齐", block[:15], nil, ErrNotBlockAligned},
+		{"填充为0", withTail(0), nil, ErrInvalidPadding},
+		{"填充大于块大小", withTail(17), nil, ErrInvalidPadding},
+		{"填充大于长度", withTail(0xff), nil, ErrInvalidPadding},
+		{"填充字节不一致", withTail(2, 3, 3), nil, ErrInvalidPadding},
+	}
+	for _, c := range cases {
+		got, err := pkcs7UnPadding(c.data, 16)
+		if !errors.Is(err, c.err) || !bytes.Equal(got, c.want) {
+			t.Errorf("%s: got %x, %v; want %x, %v", c.name, got, err, c.want, c.err)
+		}
+	}
+}
+
+func TestDecryptMalformed(t *testing.T) {
+	key := []byte("1111111111111111")
+	ed, err := Encrypt([]byte("123"), key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := Decrypt(append(ed, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0), key); !errors.Is(err, ErrNotBlockAligned) {
+		t.Errorf("未对齐密文应返回 ErrNotBlockAligned, got %v", err)
+	}
+	if _, err := DecryptLegacy(ed, key); !errors.Is(err, ErrNotBlockAligned) {
+		t.Errorf("未对齐密文应返回 ErrNotBlockAligned, got %v", err)
+	}
+	// 修改最后一个块的前一块会改变填充
+	bad := append([]byte(nil), ed...)
+	bad[len(bad)-17] ^= 0xff
+	if _, err := Decrypt(bad, key); !errors.Is(err, ErrInvalidPadding) {
+		t.Errorf("填充被篡改应返回 ErrInvalidPadding, got %v", err)
+	}
+}
+
+// referenceUnPadding 非常量时间的参考实现
+func referenceUnPadding(data []byte, blockSize int) ([]byte, bool) {
+	if len(data) == 0 || len(data)%blockSize != 0 {
+		return nil, false
+	}
+	n := int(data[len(data)-1])
+	if n == 0 || n > blockSize {
+		return nil, false
+	}
+	for _, b := range data[len(data)-n:] {
+		if int(b) != n {
+			return nil, false
+		}
+	}
+	return data[:len(data)-n], true
+}
+
+func FuzzPKCS7UnPadding(f *testing.F) {
+	f.Add(bytes.Repeat([]byte{16}, 16))
+	f.Add([]byte("aaaaaaaaaaaaaa\x02\x02"))
+	f.Add([]byte("aaaaaaaaaaaaaa\x01\x02"))
+	f.Fuzz(func(t *testing.T, data []byte) {
+		got, err := pkcs7UnPadding(data, 16)
+		want, ok := referenceUnPadding(data, 16)
+		if ok != (err == nil) || !bytes.Equal(got, want) {
+			t.Errorf("%x: got %x, %v; want %x, %v", data, got, err, want, ok)
+		}
+	})
+}
+
+func FuzzDecrypt(f *testing.F) {
+	key := []byte("1111111111111111")
+	f.Fuzz(func(t *testing.T, data []byte) {
+		// 任意输入都不能 panic
+		if dd, err := Decrypt(data, key); err == nil && len(dd) > len(data) {
+			t.Errorf("明文长度 %d 大于密文长度 %d", len(dd), len(data))
+		}
+		if dd, err := DecryptLegacy(data, key); err == nil && len(dd) > len(data) {
+			t.Errorf("明文长度 %d 大于密文长度 %d", len(dd), len(data))
+		}
+	})
+}
@@ -0,0 +1,438 @@
+package gaes
+
+import (
+	"crypto/cipher"
+	"crypto/rand"
+	"errors"
+	"fmt"
+	"io"
+	"sync"
+)
+
+// Cipher 可配置算法（AES/SM4）、模式、填充和 IV 策略的分组加密，也用于高吞吐场景
+// 密钥扩展后的分组密码和 AEAD 实例会被缓存复用，创建后不可修改，可并发使用
+//
+// 示例：
+//
+//	c, err := gaes.New(key,
+//		gaes.WithMode(gaes.ModeCTR),
+//		gaes.WithIV(iv),
+//	)
+type Cipher struct {
+	key         []byte
+	blockCipher BlockCipher
+	mode        Mode
+	padding     Padding
+	ivPolicy    IVPolicy
+	iv          []byte
+	blockSize   int
+	nonceSize   int
+	tagSize     int
+	// insecureECB 只有 WithInsecureECB 会设置，WithMode 不能选择 ECB
+	insecureECB bool
+	// states 复用密钥扩展后的实例，SM4 实现不能并发使用，每次调用独占一份
+	states sync.Pool
+}
+
+// cipherState 一份可复用的分组密码、AEAD 和 CBC 实例
+type cipherState struct {
+	block  cipher.Block
+	aead   cipher.AEAD
+	cbcEnc ivSetter
+	cbcDec ivSetter
+}
+
+// Mode 分组密码工作模式
+type Mode int
+
+const (
+	// ModeCBC CBC 模式，默认 PKCS7 填充
+	ModeCBC Mode = iota + 1
+	// ModeCTR CTR 模式，默认不填充
+	ModeCTR
+	// ModeCFB CFB 模式，默认不填充
+	ModeCFB
+	// ModeOFB OFB 模式，默认不填充
+	ModeOFB
+	// ModeGCM GCM 认证加密，nonce 为 12 字节，输出 nonce || 密文 || 标签
+	ModeGCM
+	// modeECB ECB 模式，相同明文块得到相同密文块，只能通过 WithInsecureECB 使用
+	modeECB
+)
+
+var modeNames = map[Mode]string{
+	ModeCBC: "CBC",
+	ModeCTR: "CTR",
+	ModeCFB: "CFB",
+	ModeOFB: "OFB",
+	ModeGCM: "GCM",
+	modeECB: "ECB",
+}
+
+// String 返回模式名称
+func (m Mode) String() string {
+	if name, ok := modeNames[m]; ok {
+		return name
+	}
+	return fmt.Sprintf("Mode(%d)", int(m))
+}
+
+// IVPolicy IV 的生成方式
+type IVPolicy int
+
+const (
+	// IVRandom 每次加密随机生成 IV，并放在密文前面，默认方式
+	IVRandom IVPolicy = iota
+	// IVFixed 使用 WithIV 指定的固定 IV，输出不包含 IV，仅用于与其他系统互通
+	IVFixed
+	// IVFromKey 使用 key 前 16 字节作为 IV，仅用于兼容旧数据
+	IVFromKey
+)
+
+// ErrInvalidIV IV 长度错误
+var ErrInvalidIV = errors.New("gaes: IV 长度必须等于块大小，GCM 模式 nonce 必须为 12 字节")
+
+// ErrAdditionalDataUnsupported 非认证模式不支持附加数据
+var ErrAdditionalDataUnsupported = errors.New("gaes: 非认证模式不支持附加数据")
+
+// Option Cipher 配置项
+type Option func(*Cipher)
+
+// WithMode 设置工作模式，默认 ModeCBC
+func WithMode(mode Mode) Option {
+	return func(c *Cipher) {
+		c.mode = mode
+	}
+}
+
+// WithPadding 设置填充方式，CBC 默认 PKCS7，CTR/CFB/OFB 默认不填充
+func WithPadding(padding Padding) Option {
+	return func(c *Cipher) {
+		c.padding = padding
+	}
+}
+
+// WithIV 使用固定 IV，输出不包含 IV
+// 同一密钥重复使用相同 IV 会泄露明文信息，CTR/OFB 模式下可直接还原明文，GCM 模式下还会泄露认证密钥，
+// 仅用于与其他系统互通
+func WithIV(iv []byte) Option {
+	return func(c *Cipher) {
+		c.ivPolicy = IVFixed
+		c.iv = append([]byte(nil), iv...)
+	}
+}
+
+// WithRandomIV 每次加密随机生成 IV 并放在密文前面，默认方式
+func WithRandomIV() Option {
+	return func(c *Cipher) {
+		c.ivPolicy = IVRandom
+		c.iv = nil
+	}
+}
+
+// WithKeyAsIV 使用 key 作为 IV，兼容旧版本 Encrypt 生成的数据
+func WithKeyAsIV() Option {
+	return func(c *Cipher) {
+		c.ivPolicy = IVFromKey
+		c.iv = nil
+	}
+}
+
+// WithInsecureECB 使用 ECB 模式，不安全，相同明文块得到相同密文块，仅用于与只支持 ECB 的旧系统互通
+func WithInsecureECB() Option {
+	return func(c *Cipher) {
+		c.mode = modeECB
+		c.insecureECB = true
+	}
+}
+
+// WithBlockCipher 设置分组密码算法，默认 AES
+func WithBlockCipher(b BlockCipher) Option {
+	return func(c *Cipher) {
+		c.blockCipher = b
+	}
+}
+
+// New 创建 Cipher，默认 AES-CBC、PKCS7 填充、随机 IV
+func New(key []byte, opts ...Option) (*Cipher, error) {
+	c := &Cipher{
+		key:  append([]byte(nil), key...),
+		mode: ModeCBC,
+	}
+	for _, opt := range opts {
+		opt(c)
+	}
+	if _, ok := modeNames[c.mode]; !ok || (c.mode == modeECB && !c.insecureECB) {
+		return nil, fmt.Errorf("gaes: 不支持的模式 %s", c.mode)
+	}
+	if c.padding == nil {
+		c.padding = NoPadding
+		if c.mode.isBlockMode() {
+			c.padding = PKCS7
+		}
+	}
+	st, err := c.newState()
+	if err != nil {
+		return nil, err
+	}
+	c.states.Put(st)
+	c.blockSize = st.block.BlockSize()
+	blockSize := c.blockSize
+	if c.mode == ModeGCM {
+		c.nonceSize, c.tagSize = st.aead.NonceSize(), st.aead.Overhead()
+		blockSize = c.nonceSize
+		if c.ivPolicy == IVFromKey {
+			return nil, errors.New("gaes: GCM 模式不支持 key 作为 IV")
+		}
+	}
+	switch c.ivPolicy {
+	case IVFixed:
+		if len(c.iv) != blockSize {
+			return nil, ErrInvalidIV
+		}
+	case IVFromKey:
+		c.iv = c.key[:blockSize]
+	}
+	return c, nil
+}
+
+// newState 完成密钥扩展，创建一份实例
+func (c *Cipher) newState() (*cipherState, error) {
+	block, err := c.blockCipher.newBlock(c.key)
+	if err != nil {
+		return nil, err
+	}
+	st := &cipherState{block: block}
+	if c.mode == ModeGCM {
+		if st.aead, err = cipher.NewGCM(block); err != nil {
+			return nil, err
+		}
+	}
+	return st, nil
+}
+
+// getState 从池中取出一份实例，用完后放回 c.states
+func (c *Cipher) getState() (*cipherState, error) {
+	if st, ok := c.states.Get().(*cipherState); ok {
+		return st, nil
+	}
+	return c.newState()
+}
+
+// isBlockMode 是否为需要整块输入的分组模式
+func (m Mode) isBlockMode() bool {
+	return m == ModeCBC || m == modeECB
+}
+
+// ivSize 输出中 IV 的长度，固定 IV 和 ECB 时为 0
+func (c *Cipher) ivSize() int {
+	if c.mode == modeECB || c.ivPolicy != IVRandom {
+		return 0
+	}
+	if c.mode == ModeGCM {
+		return c.nonceSize
+	}
+	return c.blockSize
+}
+
+// padded 是否需要填充
+func (c *Cipher) padded() bool {
+	return c.mode != ModeGCM && (c.mode.isBlockMode() || c.padding != NoPadding)
+}
+
+// Overhead 密文比明文最多多出的字节数，可用于预分配 Seal 的 dst
+func (c *Cipher) Overhead() int {
+	overhead := c.ivSize()
+	if c.mode == ModeGCM {
+		overhead += c.tagSize
+	} else if c.padded() {
+		overhead += c.blockSize
+	}
+	return overhead
+}
+
+// Encrypt 加密，IVRandom 策略下输出 IV || 密文
+func (c *Cipher) Encrypt(plaintext []byte) ([]byte, error) {
+	return c.Seal(nil, plaintext, nil)
+}
+
+// Decrypt 解密 Encrypt 的输出
+func (c *Cipher) Decrypt(ciphertext []byte) ([]byte, error) {
+	return c.Open(nil, ciphertext, nil)
+}
+
+// Seal 加密并把密文追加到 dst 后返回，dst 容量足够时不分配内存
+// 与 cipher.AEAD 相同，可以传入 plaintext[:0] 原地加密，容量需至少为 len(plaintext)+Overhead()
+// CBC、ECB 和 AES-GCM 使用缓存的实例，不分配内存；CTR、CFB、OFB 每次调用需创建标准库的 cipher.Stream，
+// SM4-GCM 使用标准库的通用 GCM 实现，都会有少量与数据长度无关的分配
+// additionalData 仅 GCM 模式支持
+func (c *Cipher) Seal(dst, plaintext, additionalData []byte) ([]byte, error) {
+	if c.mode != ModeGCM && len(additionalData) > 0 {
+		return nil, ErrAdditionalDataUnsupported
+	}
+	blockSize := c.blockSize
+	// 内置填充方式直接写入输出，自定义填充只对最后不足一块的部分调用 Pad
+	padLen, padding := 0, []byte(nil)
+	if c.padded() {
+		var err error
+		if p, ok := c.padding.(paddingFiller); ok {
+			padLen, err = p.padSize(len(plaintext), blockSize)
+		} else {
+			full := len(plaintext) - len(plaintext)%blockSize
+			padding, err = c.padding.Pad(plaintext[full:], blockSize)
+			padLen = len(padding) - (len(plaintext) - full)
+		}
+		if err != nil {
+			return nil, err
+		}
+		// 自定义填充的输出必须不短于输入且按块对齐，否则后续切片和 CryptBlocks 会 panic
+		if padLen < 0 || (len(plaintext)+padLen)%blockSize != 0 {
+			return nil, fmt.Errorf("%w: Pad 返回 %d 字节，不是块大小的整数倍或短于输入", ErrInvalidPadding, len(padding))
+		}
+	}
+	ivSize := c.ivSize()
+	n := ivSize + len(plaintext) + padLen
+	if c.mode == ModeGCM {
+		n += c.tagSize
+	}
+	st, err := c.getState()
+	if err != nil {
+		return nil, err
+	}
+	defer c.states.Put(st)
+	ret, out := sliceForAppend(dst, n)
+	// 先移动明文再写入 IV，copy 允许重叠，支持原地加密
+	data := out[ivSize : ivSize+len(plaintext)+padLen]
+	copy(data, plaintext)
+	if padding != nil {
+		copy(data[len(plaintext)-(len(padding)-padLen):], padding)
+	} else if padLen > 0 {
+		c.padding.(paddingFiller).fill(data[len(plaintext):], blockSize)
+	}
+	iv := c.iv
+	if ivSize > 0 {
+		iv = out[:ivSize]
+		if _, err := io.ReadFull(rand.Reader, iv); err != nil {
+			return nil, err
+		}
+	}
+	if c.mode == ModeGCM {
+		st.aead.Seal(data[:0], iv, data, additionalData)
+		return ret, nil
+	}
+	c.crypt(st, data, data, iv, true)
+	return ret, nil
+}
+
+// Open 解密并把明文追加到 dst 后返回，内存分配情况同 Seal，dst 不能与 ciphertext 重叠
+func (c *Cipher) Open(dst, ciphertext, additionalData []byte) ([]byte, error) {
+	if c.mode != ModeGCM && len(additionalData) > 0 {
+		return nil, ErrAdditionalDataUnsupported
+	}
+	iv := c.iv
+	if ivSize := c.ivSize(); ivSize > 0 {
+		if len(ciphertext) < ivSize {
+			return nil, ErrCiphertextTooShort
+		}
+		iv, ciphertext = ciphertext[:ivSize], ciphertext[ivSize:]
+	}
+	if c.mode == ModeGCM && len(ciphertext) < c.tagSize {
+		return nil, ErrCiphertextTooShort
+	}
+	blockSize := c.blockSize
+	// 零填充和不填充时空明文加密后为空，空密文交给 Unpad 判断
+	if c.mode.isBlockMode() && len(ciphertext)%blockSize != 0 {
+		return nil, ErrNotBlockAligned
+	}
+	st, err := c.getState()
+	if err != nil {
+		return nil, err
+	}
+	defer c.states.Put(st)
+	if c.mode == ModeGCM {
+		out, err := st.aead.Open(dst, iv, ciphertext, additionalData)
+		if err != nil {
+			return nil, ErrAuthentication
+		}
+		return out, nil
+	}
+	ret, out := sliceForAppend(dst, len(ciphertext))
+	c.crypt(st, out, ciphertext, iv, false)
+	if !c.padded() {
+		return ret, nil
+	}
+	plaintext, err := c.padding.Unpad(out, blockSize)
+	if err != nil {
+		return nil, err
+	}
+	return ret[:len(dst)+len(plaintext)], nil
+}
+
+// sliceForAppend 扩展 in 的长度 n，返回扩展后的切片和新增部分
+func sliceForAppend(in []byte, n int) (head, tail []byte) {
+	if total := len(in) + n; cap(in) >= total {
+		head = in[:total]
+	} else {
+		head = make([]byte, total)
+		copy(head, in)
+	}
+	tail = head[len(in):]
+	return
+}
+
+// ivSetter 可重设 IV 的 CBC 实例
+type ivSetter interface {
+	cipher.BlockMode
+	SetIV([]byte)
+}
+
+// cryptCBC 复用实例中的 CBC 加解密器
+func (c *Cipher) cryptCBC(st *cipherState, dst, src, iv []byte, encrypt bool) {
+	m := &st.cbcDec
+	if encrypt {
+		m = &st.cbcEnc
+	}
+	if *m != nil {
+		(*m).SetIV(iv)
+		(*m).CryptBlocks(dst, src)
+		return
+	}
+	var mode cipher.BlockMode
+	if encrypt {
+		mode = cipher.NewCBCEncrypter(st.block, iv)
+	} else {
+		mode = cipher.NewCBCDecrypter(st.block, iv)
+	}
+	//可重设 IV 的实现才能复用
+	if s, ok := mode.(ivSetter); ok {
+		*m = s
+	}
+	mode.CryptBlocks(dst, src)
+}
+
+// crypt 按模式加解密，分组模式下 src 长度必须是块大小的整数倍
+func (c *Cipher) crypt(st *cipherState, dst, src, iv []byte, encrypt bool) {
+	switch c.mode {
+	case ModeCBC:
+		c.cryptCBC(st, dst, src, iv, encrypt)
+	case ModeCTR:
+		cipher.NewCTR(st.block, iv).XORKeyStream(dst, src)
+	case ModeCFB:
+		if encrypt {
+			cipher.NewCFBEncrypter(st.block, iv).XORKeyStream(dst, src)
+		} else {
+			cipher.NewCFBDecrypter(st.block, iv).XORKeyStream(dst, src)
+		}
+	case ModeOFB:
+		cipher.NewOFB(st.block, iv).XORKeyStream(dst, src)
+	case modeECB:
+		blockSize := c.blockSize
+		for i := 0; i < len(src); i += blockSize {
+			if encrypt {
+				st.block.Encrypt(dst[i:i+blockSize], src[i:i+blockSize])
+			} else {
+				st.block.Decrypt(dst[i:i+blockSize], src[i:i+blockSize])
+			}
+		}
+	}
+}
@@ -0,0 +1,50 @@
+//go:build !race
+
+// 竞态检测会随机丢弃 sync.Pool 中的对象，分配次数不稳定
+
+package gaes
+
+import (
+	"bytes"
+	"testing"
+)
+
+// CBC、ECB、AES-GCM 复用缓存的实例，dst 容量足够时 Seal/Open 不分配内存
+// 其他模式每次调用创建 cipher.Stream 或通用 GCM，分配次数与数据长度无关
+func TestCipherSealNoAlloc(t *testing.T) {
+	key := []byte("1111111111111111")
+	allocs := func(c *Cipher, size int) (seal, open float64) {
+		data := bytes.Repeat([]byte{'a'}, size)
+		dst := make([]byte, 0, len(data)+c.Overhead())
+		ed, err := c.Seal(nil, data, nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		out := make([]byte, 0, len(ed))
+		seal = testing.AllocsPerRun(100, func() { c.Seal(dst, data, nil) })
+		open = testing.AllocsPerRun(100, func() { c.Open(out, ed, nil) })
+		return seal, open
+	}
+	for _, b := range []BlockCipher{AES, SM4} {
+		for _, opt := range []Option{WithMode(ModeCBC), WithMode(ModeCTR), WithMode(ModeCFB), WithMode(ModeOFB), WithMode(ModeGCM), WithInsecureECB()} {
+			for _, padding := range []Padding{nil, ISO7816, ANSIX923, ZeroPadding} {
+				opts := []Option{opt, WithBlockCipher(b)}
+				if padding != nil {
+					opts = append(opts, WithPadding(padding))
+				}
+				c, err := New(key, opts...)
+				if err != nil {
+					t.Fatal(err)
+				}
+				seal, open := allocs(c, 64)
+				pooled := c.mode == ModeCBC || c.mode == modeECB || (c.mode == ModeGCM && b == AES)
+				if pooled && (seal > 0 || open > 0) {
+					t.Errorf("%s/%s/%T: Seal/Open 不应分配内存, got %v/%v", b, c.mode, c.padding, seal, open)
+				}
+				if bigSeal, bigOpen := allocs(c, 4096); bigSeal != seal || bigOpen != open {
+					t.Errorf("%s/%s/%T: 分配次数随数据长度变化 %v/%v -> %v/%v", b, c.mode, c.padding, seal, open, bigSeal, bigOpen)
+				}
+			}
+		}
+	}
+}
@@ -0,0 +1,304 @@
+package gaes
+
+import (
+	"bytes"
+	"encoding/hex"
+	"errors"
+	"testing"
+)
+
+func mustHex(s string) []byte {
+	b, err := hex.DecodeString(s)
+	if err != nil {
+		panic(err)
+	}
+	return b
+}
+
+// NIST SP 800-38A F.1-F.5 AES-128 测试向量
+func TestCipherNISTVectors(t *testing.T) {
+	key := mustHex("2b7e151628aed2a6abf7158809cf4f3c")
+	plaintext := mustHex("6bc1bee22e409f96e93d7e117393172aae2d8a571e03ac9c9eb76fac45af8e5130c81c46a35ce411e5fbc1191a0a52eff69f2445df4f9b17ad2b417be66c3710")
+	iv := mustHex("000102030405060708090a0b0c0d0e0f")
+	cases := []struct {
+		name string
+		opts []Option
+		want string
+	}{
+		{"ECB", []Option{WithInsecureECB(), WithPadding(NoPadding)}, "3ad77bb40d7a3660a89ecaf32466ef97f5d3d58503b9699de785895a96fdbaaf43b1cd7f598ece23881b00e3ed0306887b0c785e27e8ad3f8223207104725dd4"},
+		{"CBC", []Option{WithIV(iv), WithPadding(NoPadding)}, "7649abac8119b246cee98e9b12e9197d5086cb9b507219ee95db113a917678b273bed6b8e3c1743b7116e69e222295163ff1caa1681fac09120eca307586e1a7"},
+		{"CFB", []Option{WithMode(ModeCFB), WithIV(iv)}, "3b3fd92eb72dad20333449f8e83cfb4ac8a64537a0b3a93fcde3cdad9f1ce58b26751f67a3cbb140b1808cf187a4f4dfc04b05357c5d1c0eeac4c66f9ff7f2e6"},
+		{"OFB", []Option{WithMode(ModeOFB), WithIV(iv)}, "3b3fd92eb72dad20333449f8e83cfb4a7789508d16918f03f53c52dac54ed8259740051e9c5fecf64344f7a82260edcc304c6528f659c77866a510d9c1d6ae5e"},
+		{"CTR", []Option{WithMode(ModeCTR), WithIV(mustHex("f0f1f2f3f4f5f6f7f8f9fafbfcfdfeff"))}, "874d6191b620e3261bef6864990db6ce9806f66b7970fdff8617187bb9fffdff5ae4df3edbd5d35e5b4f09020db03eab1e031dda2fbe03d1792170a0f3009cee"},
+	}
+	for _, c := range cases {
+		ci, err := New(key, c.opts...)
+		if err != nil {
+			t.Errorf("%s: %v", c.name, err)
+			continue
+		}
+		ed, err := ci.Encrypt(plaintext)
+		if err != nil {
+			t.Errorf("%s: %v", c.name, err)
+			continue
+		}
+		if hex.EncodeToString(ed) != c.want {
+			t.Errorf("%s: got %x", c.name, ed)
+		}
+		dd, err := ci.Decrypt(ed)
+		if err != nil || !bytes.Equal(dd, plaintext) {
+			t.Errorf("%s: 解密失败 %x %v", c.name, dd, err)
+		}
+	}
+}
+
+func TestCipherRandomIV(t *testing.T) {
+	key := []byte("1111111111111111")
+	data := []byte("123")
+	for _, mode := range []Mode{ModeCBC, ModeCTR, ModeCFB, ModeOFB} {
+		c, err := New(key, WithMode(mode))
+		if err != nil {
+			t.Fatal(err)
+		}
+		a, _ := c.Encrypt(data)
+		b, _ := c.Encrypt(data)
+		if bytes.Equal(a, b) {
+			t.Errorf("%s: 随机 IV 两次加密结果不应相同", mode)
+		}
+		dd, err := c.Decrypt(a)
+		if err != nil || !bytes.Equal(dd, data) {
+			t.Errorf("%s: 解密失败 %q %v", mode, dd, err)
+		}
+	}
+}
+
+func TestCipherKeyAsIV(t *testing.T) {
+	key := []byte("1111111111111111")
+	c, err := New(key, WithKeyAsIV())
+	if err != nil {
+		t.Fatal(err)
+	}
+	dd, err := c.Decrypt(legacyEncrypt([]byte("123"), key))
+	if err != nil || string(dd) != "123" {
+		t.Errorf("旧数据解密失败 %q %v", dd, err)
+	}
+}
+
+// badPadding 返回长度错误的自定义填充
+type badPadding struct{ n int }
+
+func (p badPadding) Pad(data []byte, blockSize int) ([]byte, error) {
+	return make([]byte, p.n), nil
+}
+
+func (badPadding) Unpad(data []byte, blockSize int) ([]byte, error) { return data, nil }
+
+func TestCipherInvalid(t *testing.T) {
+	key := []byte("1111111111111111")
+	if _, err := New(key, WithIV([]byte("short"))); !errors.Is(err, ErrInvalidIV) {
+		t.Errorf("IV 长度错误应返回 ErrInvalidIV, got %v", err)
+	}
+	if _, err := New(key, WithMode(Mode(255))); err == nil {
+		t.Error("未知模式应返回错误")
+	}
+	// ECB 只能通过 WithInsecureECB 显式选择，使用常量而不是数值，不依赖模式的定义顺序
+	if _, err := New(key, WithMode(modeECB)); err == nil {
+		t.Error("WithMode 选择 ECB 应返回错误")
+	}
+	if _, err := New(key, WithInsecureECB(), WithMode(modeECB)); err != nil {
+		t.Errorf("WithInsecureECB 之后应允许 ECB: %v", err)
+	}
+	c, err := New(key, WithPadding(NoPadding))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := c.Encrypt([]byte("123")); !errors.Is(err, ErrNotBlockAligned) {
+		t.Errorf("不填充时明文未对齐应返回 ErrNotBlockAligned, got %v", err)
+	}
+	if _, err := c.Decrypt(make([]byte, 20)); !errors.Is(err, ErrNotBlockAligned) {
+		t.Errorf("密文未对齐应返回 ErrNotBlockAligned, got %v", err)
+	}
+	// 自定义填充返回的长度不对齐或短于输入时返回错误而不是 panic
+	for _, n := range []int{0, 5, 17} {
+		for _, mode := range []Option{WithMode(ModeCBC), WithMode(ModeCTR), WithInsecureECB()} {
+			c, err := New(key, mode, WithPadding(badPadding{n}))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if _, err := c.Encrypt([]byte("12345678")); !errors.Is(err, ErrInvalidPadding) {
+				t.Errorf("%s: Pad 返回 %d 字节应返回 ErrInvalidPadding, got %v", c.mode, n, err)
+			}
+		}
+	}
+}
+
+func TestCipherGCM(t *testing.T) {
+	key := []byte("1111111111111111")
+	c, err := New(key, WithMode(ModeGCM))
+	if err != nil {
+		t.Fatal(err)
+	}
+	ed, err := c.Seal(nil, []byte("123"), []byte("record:1"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	// 与 OpenGCM 格式一致
+	dd, err := OpenGCM(ed, key, []byte("record:1"))
+	if err != nil || string(dd) != "123" {
+		t.Errorf("OpenGCM 解密失败: %q %v", dd, err)
+	}
+	if _, err := c.Open(nil, ed, []byte("record:2")); !errors.Is(err, ErrAuthentication) {
+		t.Errorf("附加数据不一致应认证失败, got %v", err)
+	}
+	if _, err := New(key, WithMode(ModeGCM), WithIV(make([]byte, 16))); !errors.Is(err, ErrInvalidIV) {
+		t.Errorf("GCM nonce 长度错误应返回 ErrInvalidIV, got %v", err)
+	}
+	cbc, _ := New(key)
+	if _, err := cbc.Seal(nil, []byte("123"), []byte("ad")); !errors.Is(err, ErrAdditionalDataUnsupported) {
+		t.Errorf("CBC 模式附加数据应返回 ErrAdditionalDataUnsupported, got %v", err)
+	}
+}
+
+func TestCipherSealAppend(t *testing.T) {
+	key := []byte("1111111111111111")
+	for _, mode := range []Mode{ModeCBC, ModeCTR, ModeGCM} {
+		c, err := New(key, WithMode(mode))
+		if err != nil {
+			t.Fatal(err)
+		}
+		for _, size := range []int{0, 1, 16, 33} {
+			data := bytes.Repeat([]byte{'a'}, size)
+			prefix := []byte("prefix")
+			ed, err := c.Seal(append([]byte(nil), prefix...), data, nil)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !bytes.HasPrefix(ed, prefix) || len(ed)-len(prefix) > size+c.Overhead() {
+				t.Errorf("%s/%d: 输出格式错误 %x", mode, size, ed)
+			}
+			dd, err := c.Open(append([]byte(nil), prefix...), ed[len(prefix):], nil)
+			if err != nil || !bytes.Equal(dd, append(prefix, data...)) {
+				t.Errorf("%s/%d: 解密失败 %q %v", mode, size, dd, err)
+			}
+		}
+	}
+}
+
+// 与 cipher.AEAD 相同，dst 传 plaintext[:0] 时原地加密
+func TestCipherSealInPlace(t *testing.T) {
+	key := []byte("1111111111111111")
+	for _, b := range []BlockCipher{AES, SM4} {
+		for _, opt := range []Option{WithMode(ModeCBC), WithMode(ModeCTR), WithMode(ModeCFB), WithMode(ModeOFB), WithMode(ModeGCM), WithInsecureECB(), WithIV(key)} {
+			c, err := New(key, opt, WithBlockCipher(b))
+			if err != nil {
+				t.Fatal(err)
+			}
+			for _, size := range []int{0, 1, 16, 33, 100} {
+				want := bytes.Repeat([]byte("0123456789"), 10)[:size]
+				buf := make([]byte, size, size+c.Overhead())
+				copy(buf, want)
+				ed, err := c.Seal(buf[:0], buf, nil)
+				if err != nil {
+					t.Fatal(err)
+				}
+				if len(ed) > 0 && &ed[0] != &buf[:1][0] {
+					t.Errorf("%s/%s/%d: 容量足够时应复用 plaintext 的内存", b, c.mode, size)
+				}
+				dd, err := c.Open(nil, ed, nil)
+				if err != nil || !bytes.Equal(dd, want) {
+					t.Errorf("%s/%s/%d: 原地加密后解密失败 %q %v", b, c.mode, size, dd, err)
+				}
+			}
+		}
+	}
+}
+
+func TestCipherConcurrent(t *testing.T) {
+	c, err := New([]byte("1111111111111111"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	done := make(chan error)
+	for i := 0; i < 8; i++ {
+		go func() {
+			var err error
+			for j := 0; j < 200 && err == nil; j++ {
+				var ed, dd []byte
+				if ed, err = c.Encrypt([]byte("123")); err == nil {
+					if dd, err = c.Decrypt(ed); err == nil && string(dd) != "123" {
+						err = errors.New("解密结果不一致")
+					}
+				}
+			}
+			done <- err
+		}()
+	}
+	for i := 0; i < 8; i++ {
+		if err := <-done; err != nil {
+			t.Error(err)
+		}
+	}
+}
+
+var benchRecord = bytes.Repeat([]byte{'a'}, 64)
+
+func BenchmarkEncrypt(b *testing.B) {
+	key := []byte("1111111111111111")
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		Encrypt(benchRecord, key)
+	}
+}
+
+func BenchmarkCipherEncrypt(b *testing.B) {
+	c, _ := New([]byte("1111111111111111"))
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		c.Encrypt(benchRecord)
+	}
+}
+
+func BenchmarkCipherSealCBC(b *testing.B) {
+	c, _ := New([]byte("1111111111111111"))
+	dst := make([]byte, 0, len(benchRecord)+c.Overhead())
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		c.Seal(dst, benchRecord, nil)
+	}
+}
+
+func BenchmarkDecrypt(b *testing.B) {
+	key := []byte("1111111111111111")
+	ed, _ := Encrypt(benchRecord, key)
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		Decrypt(ed, key)
+	}
+}
+
+func BenchmarkCipherOpenCBC(b *testing.B) {
+	c, _ := New([]byte("1111111111111111"))
+	ed, _ := c.Encrypt(benchRecord)
+	dst := make([]byte, 0, len(ed))
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		c.Open(dst, ed, nil)
+	}
+}
+
+func BenchmarkSealGCM(b *testing.B) {
+	key := []byte("1111111111111111")
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		SealGCM(benchRecord, key, nil)
+	}
+}
+
+func BenchmarkCipherSealGCM(b *testing.B) {
+	c, _ := New([]byte("1111111111111111"), WithMode(ModeGCM))
+	dst := make([]byte, 0, len(benchRecord)+c.Overhead())
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		c.Seal(dst, benchRecord, nil)
+	}
+}
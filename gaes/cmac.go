@@ -0,0 +1,43 @@
+package gaes
+
+import (
+	"crypto/cipher"
+	"crypto/subtle"
+)
+
+// CMAC（NIST SP 800-38B / RFC 4493），供 AES-SIV 使用
+
+// dbl GF(2^128) 上乘以 x，即左移一位，最高位为 1 时异或 0x87
+func dbl(dst, src []byte) {
+	carry := src[0] >> 7
+	for i := 0; i < len(src)-1; i++ {
+		dst[i] = src[i]<<1 | src[i+1]>>7
+	}
+	dst[len(src)-1] = src[len(src)-1]<<1 ^ byte(subtle.ConstantTimeSelect(int(carry), 0x87, 0))
+}
+
+// cmac 计算 msg 的 CMAC
+func cmac(block cipher.Block, msg []byte) []byte {
+	blockSize := block.BlockSize()
+	k1 := make([]byte, blockSize)
+	block.Encrypt(k1, k1)
+	dbl(k1, k1)
+
+	mac := make([]byte, blockSize)
+	for len(msg) > blockSize {
+		subtle.XORBytes(mac, mac, msg[:blockSize])
+		block.Encrypt(mac, mac)
+		msg = msg[blockSize:]
+	}
+	//最后一块，完整时异或 K1，不完整时补 0x80 0x00... 后异或 K2
+	last := make([]byte, blockSize)
+	copy(last, msg)
+	if len(msg) < blockSize {
+		last[len(msg)] = 0x80
+		dbl(k1, k1)
+	}
+	subtle.XORBytes(mac, mac, last)
+	subtle.XORBytes(mac, mac, k1)
+	block.Encrypt(mac, mac)
+	return mac
+}
@@ -0,0 +1,72 @@
+package gaes
+
+import (
+	"crypto/sha256"
+	"errors"
+	"hash"
+
+	"github.com/tjfoc/gmsm/sm3"
+)
+
+// 按用途派生子密钥：
+// 同一个主密钥用于多个场景（Cookie、数据库字段、文件导出）时，应为每个场景派生独立子密钥，
+// 某个子密钥泄露不会影响主密钥和其他场景。派生使用 HKDF（RFC 5869），盐为空，
+// purpose 直接作为 info，其他语言可以用标准 HKDF 得到相同的子密钥：
+//
+//	cookieKey, err := gaes.DeriveKey(master, "cookie", 32)
+//	exportKey, err := gaes.DeriveKey(master, "export:v1", 32)
+
+// minMasterKeySize 主密钥最小长度
+const minMasterKeySize = 16
+
+// ErrMasterKeyTooShort 主密钥长度不足
+var ErrMasterKeyTooShort = errors.New("gaes: 主密钥长度不能少于 16 字节")
+
+// ErrEmptyPurpose 未指定派生用途
+var ErrEmptyPurpose = errors.New("gaes: 派生用途不能为空")
+
+// DeriveKey 使用 HKDF-SHA256 从主密钥派生 purpose 用途的 length 字节子密钥
+func DeriveKey(master []byte, purpose string, length int) ([]byte, error) {
+	return deriveKey(sha256.New, master, purpose, length)
+}
+
+// DeriveKeySM3 使用 HKDF-SM3 从主密钥派生 purpose 用途的 length 字节子密钥
+func DeriveKeySM3(master []byte, purpose string, length int) ([]byte, error) {
+	return deriveKey(sm3.New, master, purpose, length)
+}
+
+func deriveKey(h func() hash.Hash, master []byte, purpose string, length int) ([]byte, error) {
+	if len(master) < minMasterKeySize {
+		return nil, ErrMasterKeyTooShort
+	}
+	if purpose == "" {
+		return nil, ErrEmptyPurpose
+	}
+	if length <= 0 || length > 255*h().Size() {
+		return nil, errors.New("gaes: 派生密钥长度超出范围")
+	}
+	return hkdfKey(h, master, nil, purpose, length)
+}
+
+// NewDerived 从主密钥派生 label 用途的子密钥并创建 Cipher，选项与 New 相同
+// AES 派生 32 字节密钥（HKDF-SHA256），SM4 派生 16 字节密钥（HKDF-SM3）
+func NewDerived(master []byte, label string, opts ...Option) (*Cipher, error) {
+	probe := &Cipher{}
+	for _, opt := range opts {
+		opt(probe)
+	}
+	var (
+		key []byte
+		err error
+	)
+	switch probe.blockCipher {
+	case SM4:
+		key, err = DeriveKeySM3(master, label, 16)
+	default:
+		key, err = DeriveKey(master, label, 32)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return New(key, opts...)
+}
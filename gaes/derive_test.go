@@ -0,0 +1,83 @@
+package gaes
+
+import (
+	"bytes"
+	"encoding/hex"
+	"errors"
+	"testing"
+)
+
+// 期望值由 Python hmac/hashlib 按 RFC 5869 计算
+func TestDeriveKey(t *testing.T) {
+	master := mustHex("000102030405060708090a0b0c0d0e0f101112131415161718191a1b1c1d1e1f")
+	cases := []struct {
+		derive  func([]byte, string, int) ([]byte, error)
+		purpose string
+		length  int
+		want    string
+	}{
+		{DeriveKey, "cookie", 32, "aea76e1461d4fdb5a81c1a07da48438c1d3023533a56993404855dcb89723b1d"},
+		{DeriveKey, "db:users.phone", 16, "743a06b2e7913be420314d54b5f265dc"},
+		{DeriveKeySM3, "cookie", 32, "47748d7c5e344dce9ae38b3188087ea638c3cf0d3b50d12154789e8a23c11473"},
+	}
+	for _, c := range cases {
+		key, err := c.derive(master, c.purpose, c.length)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if hex.EncodeToString(key) != c.want {
+			t.Errorf("%s: got %x", c.purpose, key)
+		}
+	}
+	cookie, _ := DeriveKey(master, "cookie", 32)
+	export, _ := DeriveKey(master, "export", 32)
+	if bytes.Equal(cookie, export) || bytes.Equal(cookie, master) {
+		t.Error("不同用途应派生不同的子密钥")
+	}
+}
+
+func TestDeriveKeyInvalid(t *testing.T) {
+	master := []byte("1111111111111111")
+	if _, err := DeriveKey(master[:15], "cookie", 32); !errors.Is(err, ErrMasterKeyTooShort) {
+		t.Errorf("主密钥过短应返回 ErrMasterKeyTooShort, got %v", err)
+	}
+	if _, err := DeriveKey(master, "", 32); !errors.Is(err, ErrEmptyPurpose) {
+		t.Errorf("用途为空应返回 ErrEmptyPurpose, got %v", err)
+	}
+	for _, n := range []int{0, -1, 255*32 + 1} {
+		if _, err := DeriveKey(master, "cookie", n); err == nil {
+			t.Errorf("长度 %d 应返回错误", n)
+		}
+	}
+}
+
+func TestNewDerived(t *testing.T) {
+	master := []byte("11111111111111111111111111111111")
+	c, err := NewDerived(master, "cookie", WithMode(ModeGCM))
+	if err != nil {
+		t.Fatal(err)
+	}
+	ed, err := c.Encrypt([]byte("123"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	key, _ := DeriveKey(master, "cookie", 32)
+	same, _ := New(key, WithMode(ModeGCM))
+	if dd, err := same.Decrypt(ed); err != nil || string(dd) != "123" {
+		t.Errorf("应等价于用派生密钥创建的 Cipher: %q %v", dd, err)
+	}
+	other, _ := NewDerived(master, "export", WithMode(ModeGCM))
+	if _, err := other.Decrypt(ed); !errors.Is(err, ErrAuthentication) {
+		t.Errorf("不同用途的 Cipher 不能解密, got %v", err)
+	}
+	sm4c, err := NewDerived(master, "cookie", WithBlockCipher(SM4), WithMode(ModeGCM))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if ed, err = sm4c.Encrypt([]byte("123")); err != nil {
+		t.Fatal(err)
+	}
+	if dd, err := sm4c.Decrypt(ed); err != nil || string(dd) != "123" {
+		t.Errorf("SM4 解密失败 %q %v", dd, err)
+	}
+}
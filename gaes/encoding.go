@@ -0,0 +1,137 @@
+package gaes
+
+import (
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// Encoding 密文的字符串编码
+type Encoding int
+
+const (
+	// StdEncoding 标准 base64，带填充
+	StdEncoding Encoding = iota
+	// RawURLEncoding URL 安全的 base64，不带填充，适用于 URL、Cookie
+	RawURLEncoding
+	// HexEncoding 十六进制
+	HexEncoding
+)
+
+// encodeToString 编码为字符串
+func (e Encoding) encodeToString(data []byte) (string, error) {
+	switch e {
+	case StdEncoding:
+		return base64.StdEncoding.EncodeToString(data), nil
+	case RawURLEncoding:
+		return base64.RawURLEncoding.EncodeToString(data), nil
+	case HexEncoding:
+		return hex.EncodeToString(data), nil
+	}
+	return "", fmt.Errorf("gaes: 未知编码 %d", int(e))
+}
+
+// decodeString 解析字符串
+func (e Encoding) decodeString(s string) ([]byte, error) {
+	switch e {
+	case StdEncoding:
+		return base64.StdEncoding.DecodeString(s)
+	case RawURLEncoding:
+		return base64.RawURLEncoding.DecodeString(s)
+	case HexEncoding:
+		return hex.DecodeString(s)
+	}
+	return nil, fmt.Errorf("gaes: 未知编码 %d", int(e))
+}
+
+// EncryptToString 使用 Encrypt 加密并编码为字符串
+func EncryptToString(data, key []byte, enc Encoding) (string, error) {
+	crypted, err := Encrypt(data, key)
+	if err != nil {
+		return "", err
+	}
+	return enc.encodeToString(crypted)
+}
+
+// DecryptString 解析 EncryptToString 的输出并解密
+func DecryptString(s string, key []byte, enc Encoding) ([]byte, error) {
+	data, err := enc.decodeString(s)
+	if err != nil {
+		return nil, err
+	}
+	return Decrypt(data, key)
+}
+
+// 加密令牌，用于 Cookie、邮件链接等需要过期时间的场景
+//
+// 格式（URL 安全 base64，不带填充）：
+//  版本号(1) || AES-GCM(nonce || 过期时间(8) || 载荷 || 标签)
+// 过期时间为 Unix 秒，和载荷一起加密，版本号作为附加认证数据
+
+// ErrTokenExpired 令牌已过期
+var ErrTokenExpired = errors.New("gaes: 令牌已过期")
+
+// ErrInvalidToken 令牌格式错误或被篡改
+var ErrInvalidToken = errors.New("gaes: 令牌无效")
+
+// now 当前时间，测试时可替换
+var now = time.Now
+
+// Token 带过期时间的加密令牌
+type Token struct {
+	Payload   []byte
+	ExpiresAt time.Time
+}
+
+// NewToken 创建 ttl 后过期的令牌
+func NewToken(payload []byte, ttl time.Duration) *Token {
+	return &Token{Payload: payload, ExpiresAt: now().Add(ttl)}
+}
+
+// Expired 是否已过期
+func (t *Token) Expired() bool {
+	return !now().Before(t.ExpiresAt)
+}
+
+// Encrypt 加密令牌，返回 URL 安全的字符串
+func (t *Token) Encrypt(key []byte) (string, error) {
+	plaintext := make([]byte, 8, 8+len(t.Payload))
+	binary.BigEndian.PutUint64(plaintext, uint64(t.ExpiresAt.Unix()))
+	plaintext = append(plaintext, t.Payload...)
+	header := []byte{formatToken}
+	sealed, err := SealGCM(plaintext, key, header)
+	if err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(append(header, sealed...)), nil
+}
+
+// ParseToken 解密并校验令牌，被篡改返回 ErrInvalidToken，已过期返回 ErrTokenExpired
+func ParseToken(s string, key []byte) (*Token, error) {
+	data, err := base64.RawURLEncoding.DecodeString(strings.TrimRight(s, "="))
+	if err != nil || len(data) < 1 || data[0] != formatToken {
+		return nil, ErrInvalidToken
+	}
+	plaintext, err := OpenGCM(data[1:], key, data[:1])
+	if errors.Is(err, ErrAuthentication) || errors.Is(err, ErrCiphertextTooShort) {
+		return nil, ErrInvalidToken
+	}
+	if err != nil {
+		return nil, err
+	}
+	if len(plaintext) < 8 {
+		return nil, ErrInvalidToken
+	}
+	t := &Token{
+		ExpiresAt: time.Unix(int64(binary.BigEndian.Uint64(plaintext)), 0),
+		Payload:   plaintext[8:],
+	}
+	if t.Expired() {
+		return nil, ErrTokenExpired
+	}
+	return t, nil
+}
@@ -0,0 +1,66 @@
+package gaes
+
+import (
+	"errors"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestEncryptToString(t *testing.T) {
+	key := []byte("1111111111111111")
+	for _, enc := range []Encoding{StdEncoding, RawURLEncoding, HexEncoding} {
+		s, err := EncryptToString([]byte("123"), key, enc)
+		if err != nil {
+			t.Error(err)
+			continue
+		}
+		if enc == RawURLEncoding && strings.ContainsAny(s, "+/=") {
+			t.Errorf("URL 安全编码包含非法字符: %s", s)
+		}
+		dd, err := DecryptString(s, key, enc)
+		if err != nil || string(dd) != "123" {
+			t.Errorf("编码 %d 解密失败: %q %v", enc, dd, err)
+		}
+	}
+	if _, err := EncryptToString([]byte("123"), key, Encoding(9)); err == nil {
+		t.Error("未知编码应返回错误")
+	}
+}
+
+func TestToken(t *testing.T) {
+	defer func() { now = time.Now }()
+	current := time.Unix(1700000000, 0)
+	now = func() time.Time { return current }
+
+	key := []byte("1111111111111111")
+	s, err := NewToken([]byte("uid=1"), time.Hour).Encrypt(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.ContainsAny(s, "+/=") {
+		t.Errorf("令牌包含非 URL 安全字符: %s", s)
+	}
+	token, err := ParseToken(s, key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(token.Payload) != "uid=1" || !token.ExpiresAt.Equal(current.Add(time.Hour)) {
+		t.Errorf("令牌内容不一致: %+v", token)
+	}
+	// 修改中间一个字符，末尾字符可能只含未使用的比特位
+	i, c := len(s)/2, "A"
+	if s[i] == 'A' {
+		c = "B"
+	}
+	if _, err := ParseToken(s[:i]+c+s[i+1:], key); !errors.Is(err, ErrInvalidToken) {
+		t.Errorf("篡改令牌应返回 ErrInvalidToken, got %v", err)
+	}
+	if _, err := ParseToken("!!", key); !errors.Is(err, ErrInvalidToken) {
+		t.Errorf("格式错误应返回 ErrInvalidToken, got %v", err)
+	}
+	current = current.Add(time.Hour)
+	if _, err := ParseToken(s, key); !errors.Is(err, ErrTokenExpired) {
+		t.Errorf("过期令牌应返回 ErrTokenExpired, got %v", err)
+	}
+}
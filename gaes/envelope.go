@@ -0,0 +1,361 @@
+package gaes
+
+import (
+	"bytes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// 自描述密文信封，记录格式版本、算法、密钥 ID、nonce 和认证标签，便于密钥轮换和算法升级
+//
+// 二进制格式：
+//  版本号(1) || 算法(1) || 密钥ID长度(1) || 密钥ID || nonce长度(1) || nonce || 标签长度(1) || 标签 || 密文
+// 文本格式（base64url 无填充，以 . 分隔）：
+//  版本号.算法名.密钥ID.nonce.密文.标签
+// 认证类算法（GCM）会把 版本号、算法和密钥ID 一起纳入附加认证数据，防止被替换。
+// CBC 算法不认证信封头和密文，篡改无法被发现，且不支持附加数据，仅用于解密历史数据或与只支持 CBC 的系统互通，
+// 新数据应使用 GCM，只能使用 CBC 时应使用 EncryptThenMAC
+
+// Algorithm 信封使用的加密算法
+type Algorithm byte
+
+const (
+	// AlgAESGCM AES-GCM 认证加密，推荐使用
+	AlgAESGCM Algorithm = 1
+	// AlgAESCBC AES-CBC + PKCS7 随机 IV，不认证信封头和密文，不支持附加数据，仅用于兼容
+	AlgAESCBC Algorithm = 2
+	// AlgSM4GCM SM4-GCM 认证加密（RFC 8998）
+	AlgSM4GCM Algorithm = 3
+	// AlgSM4CBC SM4-CBC + PKCS7 随机 IV，不认证信封头和密文，不支持附加数据，仅用于兼容
+	AlgSM4CBC Algorithm = 4
+)
+
+var algorithmNames = map[Algorithm]string{
+	AlgAESGCM: "aes-gcm",
+	AlgAESCBC: "aes-cbc",
+	AlgSM4GCM: "sm4-gcm",
+	AlgSM4CBC: "sm4-cbc",
+}
+
+// String 返回算法名称
+func (a Algorithm) String() string {
+	if name, ok := algorithmNames[a]; ok {
+		return name
+	}
+	return fmt.Sprintf("Algorithm(%d)", byte(a))
+}
+
+// ParseAlgorithm 根据名称解析算法
+func ParseAlgorithm(name string) (Algorithm, error) {
+	for alg, n := range algorithmNames {
+		if n == name {
+			return alg, nil
+		}
+	}
+	return 0, fmt.Errorf("%w: %s", ErrUnsupportedAlgorithm, name)
+}
+
+// ErrUnsupportedAlgorithm 不支持的算法
+var ErrUnsupportedAlgorithm = errors.New("gaes: 不支持的算法")
+
+// ErrInvalidEnvelope 信封格式错误
+var ErrInvalidEnvelope = errors.New("gaes: 信封格式错误")
+
+// ErrKeyNotFound 找不到密钥 ID 对应的密钥
+var ErrKeyNotFound = errors.New("gaes: 密钥不存在")
+
+// KeyResolver 根据密钥 ID 查找解密密钥
+type KeyResolver interface {
+	ResolveKey(keyID string) ([]byte, error)
+}
+
+// Keys 以密钥 ID 为 key 的静态密钥表
+type Keys map[string][]byte
+
+// ResolveKey 实现 KeyResolver
+func (k Keys) ResolveKey(keyID string) ([]byte, error) {
+	key, ok := k[keyID]
+	if !ok {
+		return nil, fmt.Errorf("%w: %q", ErrKeyNotFound, keyID)
+	}
+	return key, nil
+}
+
+// Envelope 自描述密文信封
+type Envelope struct {
+	Algorithm  Algorithm
+	KeyID      string
+	Nonce      []byte
+	Ciphertext []byte
+	Tag        []byte
+}
+
+// envelopeCipher 信封算法的加解密实现
+type envelopeCipher struct {
+	// seal 加密，返回 nonce、密文和标签
+	seal func(key, plaintext, aad []byte) (nonce, ciphertext, tag []byte, err error)
+	// open 校验并解密
+	open func(key, nonce, ciphertext, tag, aad []byte) ([]byte, error)
+	// authenticated 是否认证信封头和附加数据
+	authenticated bool
+}
+
+var envelopeCiphers = map[Algorithm]envelopeCipher{
+	AlgAESGCM: gcmEnvelope(AES),
+	AlgAESCBC: cbcEnvelope(AES),
+	AlgSM4GCM: gcmEnvelope(SM4),
+	AlgSM4CBC: cbcEnvelope(SM4),
+}
+
+// SealEnvelope 使用指定算法和密钥加密，返回信封
+func SealEnvelope(alg Algorithm, keyID string, key, plaintext, additionalData []byte) (*Envelope, error) {
+	c, ok := envelopeCiphers[alg]
+	if !ok {
+		return nil, fmt.Errorf("%w: %s", ErrUnsupportedAlgorithm, alg)
+	}
+	if len(keyID) > 255 {
+		return nil, fmt.Errorf("%w: 密钥ID过长", ErrInvalidEnvelope)
+	}
+	// 不认证的算法忽略附加数据会让调用方误以为数据已绑定上下文
+	if !c.authenticated && len(additionalData) > 0 {
+		return nil, fmt.Errorf("%w: %s", ErrAdditionalDataUnsupported, alg)
+	}
+	env := &Envelope{Algorithm: alg, KeyID: keyID}
+	nonce, ciphertext, tag, err := c.seal(key, plaintext, env.aad(additionalData))
+	if err != nil {
+		return nil, err
+	}
+	env.Nonce, env.Ciphertext, env.Tag = nonce, ciphertext, tag
+	return env, nil
+}
+
+// Open 根据信封中的算法和密钥 ID 自动选择算法和密钥解密
+func (e *Envelope) Open(keys KeyResolver, additionalData []byte) ([]byte, error) {
+	c, ok := envelopeCiphers[e.Algorithm]
+	if !ok {
+		return nil, fmt.Errorf("%w: %s", ErrUnsupportedAlgorithm, e.Algorithm)
+	}
+	if !c.authenticated && len(additionalData) > 0 {
+		return nil, fmt.Errorf("%w: %s", ErrAdditionalDataUnsupported, e.Algorithm)
+	}
+	key, err := keys.ResolveKey(e.KeyID)
+	if err != nil {
+		return nil, err
+	}
+	return c.open(key, e.Nonce, e.Ciphertext, e.Tag, e.aad(additionalData))
+}
+
+// OpenEnvelope 解析二进制或文本格式的信封并解密
+func OpenEnvelope(data []byte, keys KeyResolver, additionalData []byte) ([]byte, error) {
+	env, err := ParseEnvelope(data)
+	if err != nil {
+		return nil, err
+	}
+	return env.Open(keys, additionalData)
+}
+
+// ParseEnvelope 解析信封，自动识别二进制和文本格式
+func ParseEnvelope(data []byte) (*Envelope, error) {
+	env := new(Envelope)
+	var err error
+	if len(data) > 0 && data[0] == formatEnvelope {
+		err = env.UnmarshalBinary(data)
+	} else {
+		err = env.UnmarshalText(data)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return env, nil
+}
+
+// header 版本号 || 算法 || 密钥ID长度 || 密钥ID
+func (e *Envelope) header() []byte {
+	h := make([]byte, 0, 3+len(e.KeyID))
+	h = append(h, formatEnvelope, byte(e.Algorithm), byte(len(e.KeyID)))
+	return append(h, e.KeyID...)
+}
+
+// aad 信封头与调用方附加数据一起参与认证
+func (e *Envelope) aad(additionalData []byte) []byte {
+	return append(e.header(), additionalData...)
+}
+
+// MarshalBinary 编码为二进制格式
+func (e *Envelope) MarshalBinary() ([]byte, error) {
+	if len(e.KeyID) > 255 || len(e.Nonce) > 255 || len(e.Tag) > 255 {
+		return nil, ErrInvalidEnvelope
+	}
+	out := e.header()
+	out = append(out, byte(len(e.Nonce)))
+	out = append(out, e.Nonce...)
+	out = append(out, byte(len(e.Tag)))
+	out = append(out, e.Tag...)
+	return append(out, e.Ciphertext...), nil
+}
+
+// UnmarshalBinary 解析二进制格式
+func (e *Envelope) UnmarshalBinary(data []byte) error {
+	if len(data) < 2 || data[0] != formatEnvelope {
+		return ErrInvalidEnvelope
+	}
+	alg := Algorithm(data[1])
+	rest := data[2:]
+	var keyID, nonce, tag []byte
+	var ok bool
+	if keyID, rest, ok = readShort(rest); !ok {
+		return ErrInvalidEnvelope
+	}
+	if nonce, rest, ok = readShort(rest); !ok {
+		return ErrInvalidEnvelope
+	}
+	if tag, rest, ok = readShort(rest); !ok {
+		return ErrInvalidEnvelope
+	}
+	e.Algorithm = alg
+	e.KeyID = string(keyID)
+	e.Nonce = append([]byte(nil), nonce...)
+	e.Tag = append([]byte(nil), tag...)
+	e.Ciphertext = append([]byte(nil), rest...)
+	return nil
+}
+
+// readShort 读取 1 字节长度前缀的字段
+func readShort(data []byte) (field, rest []byte, ok bool) {
+	if len(data) < 1 || len(data) < 1+int(data[0]) {
+		return nil, nil, false
+	}
+	n := 1 + int(data[0])
+	return data[1:n], data[n:], true
+}
+
+// MarshalText 编码为紧凑文本格式
+func (e *Envelope) MarshalText() ([]byte, error) {
+	if len(e.KeyID) > 255 || len(e.Nonce) > 255 || len(e.Tag) > 255 {
+		return nil, ErrInvalidEnvelope
+	}
+	enc := base64.RawURLEncoding
+	parts := []string{
+		fmt.Sprintf("%d", formatEnvelope),
+		e.Algorithm.String(),
+		enc.EncodeToString([]byte(e.KeyID)),
+		enc.EncodeToString(e.Nonce),
+		enc.EncodeToString(e.Ciphertext),
+		enc.EncodeToString(e.Tag),
+	}
+	return []byte(strings.Join(parts, ".")), nil
+}
+
+// UnmarshalText 解析紧凑文本格式
+func (e *Envelope) UnmarshalText(text []byte) error {
+	parts := strings.Split(string(bytes.TrimSpace(text)), ".")
+	if len(parts) != 6 || parts[0] != fmt.Sprintf("%d", formatEnvelope) {
+		return ErrInvalidEnvelope
+	}
+	alg, err := ParseAlgorithm(parts[1])
+	if err != nil {
+		return err
+	}
+	enc := base64.RawURLEncoding
+	fields := make([][]byte, 4)
+	for i, p := range parts[2:] {
+		if fields[i], err = enc.DecodeString(p); err != nil {
+			return fmt.Errorf("%w: %v", ErrInvalidEnvelope, err)
+		}
+	}
+	if len(fields[0]) > 255 {
+		return ErrInvalidEnvelope
+	}
+	e.Algorithm = alg
+	e.KeyID = string(fields[0])
+	e.Nonce, e.Ciphertext, e.Tag = fields[1], fields[2], fields[3]
+	return nil
+}
+
+// String 返回紧凑文本格式
+func (e *Envelope) String() string {
+	text, err := e.MarshalText()
+	if err != nil {
+		return ""
+	}
+	return string(text)
+}
+
+// gcmEnvelope GCM 模式的信封算法
+func gcmEnvelope(b BlockCipher) envelopeCipher {
+	newAEAD := func(key []byte) (cipher.AEAD, error) {
+		block, err := b.newBlock(key)
+		if err != nil {
+			return nil, err
+		}
+		return cipher.NewGCM(block)
+	}
+	return envelopeCipher{
+		authenticated: true,
+		seal: func(key, plaintext, aad []byte) (nonce, ciphertext, tag []byte, err error) {
+			aead, err := newAEAD(key)
+			if err != nil {
+				return nil, nil, nil, err
+			}
+			nonce = make([]byte, aead.NonceSize())
+			if _, err := io.ReadFull(rand.Reader, nonce); err != nil {
+				return nil, nil, nil, err
+			}
+			sealed := aead.Seal(nil, nonce, plaintext, aad)
+			n := len(sealed) - aead.Overhead()
+			return nonce, sealed[:n], sealed[n:], nil
+		},
+		open: func(key, nonce, ciphertext, tag, aad []byte) ([]byte, error) {
+			aead, err := newAEAD(key)
+			if err != nil {
+				return nil, err
+			}
+			if len(nonce) != aead.NonceSize() || len(tag) != aead.Overhead() {
+				return nil, ErrInvalidEnvelope
+			}
+			sealed := make([]byte, 0, len(ciphertext)+len(tag))
+			sealed = append(append(sealed, ciphertext...), tag...)
+			plaintext, err := aead.Open(nil, nonce, sealed, aad)
+			if err != nil {
+				return nil, ErrAuthentication
+			}
+			return plaintext, nil
+		},
+	}
+}
+
+// cbcEnvelope CBC 模式的信封算法，不产生标签，信封头不参与运算，调用方不能传入附加数据
+func cbcEnvelope(b BlockCipher) envelopeCipher {
+	return envelopeCipher{
+		seal: func(key, plaintext, _ []byte) (nonce, ciphertext, tag []byte, err error) {
+			block, err := b.newBlock(key)
+			if err != nil {
+				return nil, nil, nil, err
+			}
+			nonce = make([]byte, block.BlockSize())
+			if _, err := io.ReadFull(rand.Reader, nonce); err != nil {
+				return nil, nil, nil, err
+			}
+			padded, _ := PKCS7.Pad(plaintext, block.BlockSize())
+			ciphertext = make([]byte, len(padded))
+			cipher.NewCBCEncrypter(block, nonce).CryptBlocks(ciphertext, padded)
+			return nonce, ciphertext, nil, nil
+		},
+		open: func(key, nonce, ciphertext, tag, _ []byte) ([]byte, error) {
+			block, err := b.newBlock(key)
+			if err != nil {
+				return nil, err
+			}
+			blockSize := block.BlockSize()
+			if len(nonce) != blockSize || len(tag) != 0 || len(ciphertext) == 0 || len(ciphertext)%blockSize != 0 {
+				return nil, ErrInvalidEnvelope
+			}
+			return decryptCBC(block, nonce, ciphertext)
+		},
+	}
+}
@@ -0,0 +1,88 @@
+package gaes
+
+import (
+	"bytes"
+	"errors"
+	"testing"
+)
+
+func TestEnvelope(t *testing.T) {
+	keys := Keys{
+		"2024q1": []byte("1111111111111111"),
+		"2024q2": []byte("22222222222222222222222222222222"),
+	}
+	data := []byte("123")
+	ad := []byte("user:1")
+	for _, alg := range []Algorithm{AlgAESGCM, AlgAESCBC} {
+		ad := ad
+		if alg == AlgAESCBC {
+			// CBC 不认证，不支持附加数据
+			ad = nil
+		}
+		env, err := SealEnvelope(alg, "2024q2", keys["2024q2"], data, ad)
+		if err != nil {
+			t.Error(err)
+			return
+		}
+		bin, err := env.MarshalBinary()
+		if err != nil {
+			t.Error(err)
+			return
+		}
+		for _, encoded := range [][]byte{bin, []byte(env.String())} {
+			dd, err := OpenEnvelope(encoded, keys, ad)
+			if err != nil {
+				t.Errorf("%s: %v", alg, err)
+				continue
+			}
+			if !bytes.Equal(dd, data) {
+				t.Errorf("%s: 解密结果不一致: %q", alg, dd)
+			}
+		}
+		t.Log(env)
+	}
+}
+
+func TestEnvelopeTampered(t *testing.T) {
+	keys := Keys{
+		"a": []byte("1111111111111111"),
+		"b": []byte("1111111111111111"),
+	}
+	env, err := SealEnvelope(AlgAESGCM, "a", keys["a"], []byte("123"), nil)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	// 替换密钥 ID 即使密钥相同也应认证失败
+	env.KeyID = "b"
+	if _, err := env.Open(keys, nil); !errors.Is(err, ErrAuthentication) {
+		t.Errorf("替换密钥ID应认证失败, got %v", err)
+	}
+	env.KeyID = "c"
+	if _, err := env.Open(keys, nil); !errors.Is(err, ErrKeyNotFound) {
+		t.Errorf("未知密钥ID应返回 ErrKeyNotFound, got %v", err)
+	}
+	if _, err := ParseEnvelope([]byte{formatEnvelope, 1, 5, 'a'}); !errors.Is(err, ErrInvalidEnvelope) {
+		t.Errorf("截断信封应返回 ErrInvalidEnvelope, got %v", err)
+	}
+	if _, err := ParseEnvelope([]byte("2.des.YQ...")); !errors.Is(err, ErrUnsupportedAlgorithm) {
+		t.Errorf("未知算法应返回 ErrUnsupportedAlgorithm, got %v", err)
+	}
+}
+
+// CBC 信封不认证，传入附加数据时返回错误，避免调用方误以为数据已绑定上下文
+func TestEnvelopeCBCAdditionalData(t *testing.T) {
+	key := []byte("1111111111111111")
+	for _, alg := range []Algorithm{AlgAESCBC, AlgSM4CBC} {
+		if _, err := SealEnvelope(alg, "k", key, []byte("123"), []byte("user:1")); !errors.Is(err, ErrAdditionalDataUnsupported) {
+			t.Errorf("%s: Seal 应返回 ErrAdditionalDataUnsupported, got %v", alg, err)
+		}
+		env, err := SealEnvelope(alg, "k", key, []byte("123"), nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, err := env.Open(Keys{"k": key}, []byte("user:1")); !errors.Is(err, ErrAdditionalDataUnsupported) {
+			t.Errorf("%s: Open 应返回 ErrAdditionalDataUnsupported, got %v", alg, err)
+		}
+	}
+}
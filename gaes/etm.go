@@ -0,0 +1,165 @@
+package gaes
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/binary"
+	"fmt"
+	"hash"
+	"io"
+
+	"github.com/tjfoc/gmsm/sm3"
+)
+
+// AES-CBC + HMAC 先加密后认证（Encrypt-then-MAC），用于只能使用 CBC 的对接方：
+//  1、从一个主密钥通过 HKDF 派生相互独立的加密密钥和 MAC 密钥，加密密钥与主密钥等长
+//  2、MAC 覆盖 头部 || IV || 密文 || 附加数据 || 附加数据长度（8 字节大端，单位比特）
+//  3、解密时先以常量时间校验完整标签，通过后才解密和去除填充，
+//     因此不会像 Decrypt 那样暴露填充错误，也无法篡改密文
+// 输出格式为 版本号 || MAC 算法 || IV(16) || 密文 || 标签
+
+// MAC 消息认证码算法
+type MAC byte
+
+const (
+	// HMACSHA256 HMAC-SHA256，标签 32 字节
+	HMACSHA256 MAC = iota + 1
+	// HMACSM3 国密 SM3-HMAC，标签 32 字节
+	HMACSM3
+)
+
+// String 返回算法名称
+func (m MAC) String() string {
+	switch m {
+	case HMACSHA256:
+		return "hmac-sha256"
+	case HMACSM3:
+		return "hmac-sm3"
+	}
+	return fmt.Sprintf("mac(%d)", byte(m))
+}
+
+// hash 返回 MAC 算法对应的哈希函数
+func (m MAC) hash() (func() hash.Hash, error) {
+	switch m {
+	case HMACSHA256:
+		return sha256.New, nil
+	case HMACSM3:
+		return sm3.New, nil
+	}
+	return nil, ErrUnsupportedAlgorithm
+}
+
+// EncryptThenMAC AES-CBC + HMAC 认证加密，可复用、可并发使用
+type EncryptThenMAC struct {
+	block  cipher.Block
+	macKey []byte
+	mac    MAC
+	hash   func() hash.Hash
+}
+
+// NewEncryptThenMAC 从主密钥派生加密密钥和 MAC 密钥，主密钥长度为 16、24 或 32 字节
+func NewEncryptThenMAC(masterKey []byte, mac MAC) (*EncryptThenMAC, error) {
+	h, err := mac.hash()
+	if err != nil {
+		return nil, err
+	}
+	encKey, err := hkdfKey(h, masterKey, nil, "gaes etm encryption", len(masterKey))
+	if err != nil {
+		return nil, err
+	}
+	block, err := aes.NewCipher(encKey)
+	if err != nil {
+		return nil, err
+	}
+	macKey, err := hkdfKey(h, masterKey, nil, "gaes etm authentication", h().Size())
+	if err != nil {
+		return nil, err
+	}
+	return &EncryptThenMAC{block: block, macKey: macKey, mac: mac, hash: h}, nil
+}
+
+// Overhead 返回密文比明文最多多出的字节数
+func (e *EncryptThenMAC) Overhead() int {
+	return 2 + 2*aes.BlockSize + e.hash().Size()
+}
+
+// tag 计算 头部 || IV || 密文 || 附加数据 || 附加数据比特长度 的 MAC
+func (e *EncryptThenMAC) tag(dst, body, additionalData []byte) []byte {
+	m := hmac.New(e.hash, e.macKey)
+	m.Write(body)
+	m.Write(additionalData)
+	var length [8]byte
+	binary.BigEndian.PutUint64(length[:], uint64(len(additionalData))*8)
+	m.Write(length[:])
+	// tjfoc 的 SM3 实现 Sum 不支持追加到非空切片，先求值再追加
+	return append(dst, m.Sum(nil)...)
+}
+
+// Seal 加密并认证 plaintext，additionalData 只参与认证
+func (e *EncryptThenMAC) Seal(plaintext, additionalData []byte) ([]byte, error) {
+	// 不能直接追加填充，plaintext 可能是调用方更大缓冲区的子切片
+	padded, err := PKCS7.Pad(plaintext, aes.BlockSize)
+	if err != nil {
+		return nil, err
+	}
+	out := make([]byte, 2+aes.BlockSize+len(padded), 2+aes.BlockSize+len(padded)+e.hash().Size())
+	out[0] = formatEtM
+	out[1] = byte(e.mac)
+	iv := out[2 : 2+aes.BlockSize]
+	if _, err := io.ReadFull(rand.Reader, iv); err != nil {
+		return nil, err
+	}
+	cipher.NewCBCEncrypter(e.block, iv).CryptBlocks(out[2+aes.BlockSize:], padded)
+	return e.tag(out, out, additionalData), nil
+}
+
+// Open 先校验标签再解密 Seal 的输出，标签不匹配返回 ErrAuthentication
+func (e *EncryptThenMAC) Open(ciphertext, additionalData []byte) ([]byte, error) {
+	tagSize := e.hash().Size()
+	if len(ciphertext) < 2+2*aes.BlockSize+tagSize {
+		return nil, ErrCiphertextTooShort
+	}
+	if ciphertext[0] != formatEtM {
+		return nil, ErrUnknownFormat
+	}
+	if MAC(ciphertext[1]) != e.mac {
+		return nil, ErrUnsupportedAlgorithm
+	}
+	body, tag := ciphertext[:len(ciphertext)-tagSize], ciphertext[len(ciphertext)-tagSize:]
+	if !hmac.Equal(e.tag(nil, body, additionalData), tag) {
+		return nil, ErrAuthentication
+	}
+	data := body[2+aes.BlockSize:]
+	if len(data)%aes.BlockSize != 0 {
+		return nil, ErrNotBlockAligned
+	}
+	return decryptCBC(e.block, body[2:2+aes.BlockSize], data)
+}
+
+// SealEtM 使用主密钥做一次性 Encrypt-then-MAC 加密，多次加密应复用 NewEncryptThenMAC 的结果
+func SealEtM(plaintext, masterKey, additionalData []byte, mac MAC) ([]byte, error) {
+	e, err := NewEncryptThenMAC(masterKey, mac)
+	if err != nil {
+		return nil, err
+	}
+	return e.Seal(plaintext, additionalData)
+}
+
+// OpenEtM 校验并解密 SealEtM 的输出，MAC 算法从密文中读取
+func OpenEtM(ciphertext, masterKey, additionalData []byte) ([]byte, error) {
+	if len(ciphertext) < 2 {
+		return nil, ErrCiphertextTooShort
+	}
+	if ciphertext[0] != formatEtM {
+		return nil, ErrUnknownFormat
+	}
+	e, err := NewEncryptThenMAC(masterKey, MAC(ciphertext[1]))
+	if err != nil {
+		return nil, err
+	}
+	return e.Open(ciphertext, additionalData)
+}
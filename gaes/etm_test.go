@@ -0,0 +1,114 @@
+package gaes
+
+import (
+	"bytes"
+	"crypto/aes"
+	"errors"
+	"testing"
+)
+
+func TestEncryptThenMAC(t *testing.T) {
+	key := []byte("1111111111111111")
+	ad := []byte("partner:1")
+	for _, mac := range []MAC{HMACSHA256, HMACSM3} {
+		for _, n := range []int{0, 3, 16, 100} {
+			data := bytes.Repeat([]byte("a"), n)
+			ed, err := SealEtM(data, key, ad, mac)
+			if err != nil {
+				t.Fatal(err)
+			}
+			e, _ := NewEncryptThenMAC(key, mac)
+			if len(ed) > n+e.Overhead() {
+				t.Errorf("%s: 密文长度 %d 超过 Overhead", mac, len(ed))
+			}
+			dd, err := OpenEtM(ed, key, ad)
+			if err != nil {
+				t.Errorf("%s: %v", mac, err)
+				continue
+			}
+			if !bytes.Equal(dd, data) {
+				t.Errorf("%s: 解密结果不一致: %q", mac, dd)
+			}
+		}
+	}
+}
+
+func TestEncryptThenMACTampered(t *testing.T) {
+	key := []byte("11111111111111111111111111111111")
+	for _, mac := range []MAC{HMACSHA256, HMACSM3} {
+		e, err := NewEncryptThenMAC(key, mac)
+		if err != nil {
+			t.Fatal(err)
+		}
+		ed, err := e.Seal([]byte("service token for partner"), nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		// 任何字节被篡改都应在去除填充之前认证失败，不能返回 ErrInvalidPadding
+		for i := 2; i < len(ed); i++ {
+			bad := append([]byte(nil), ed...)
+			bad[i] ^= 0x01
+			if _, err := e.Open(bad, nil); !errors.Is(err, ErrAuthentication) {
+				t.Errorf("%s: 篡改第 %d 字节后应认证失败, got %v", mac, i, err)
+			}
+		}
+		if _, err := e.Open(ed, []byte("other")); !errors.Is(err, ErrAuthentication) {
+			t.Errorf("%s: 附加数据不一致应认证失败, got %v", mac, err)
+		}
+		if _, err := e.Open(ed[:len(ed)-16], nil); !errors.Is(err, ErrAuthentication) {
+			t.Errorf("%s: 截断密文应认证失败, got %v", mac, err)
+		}
+		if _, err := e.Open(ed[:40], nil); !errors.Is(err, ErrCiphertextTooShort) {
+			t.Errorf("%s: 过短密文应返回 ErrCiphertextTooShort, got %v", mac, err)
+		}
+		if _, err := OpenEtM(ed, []byte("22222222222222222222222222222222"), nil); !errors.Is(err, ErrAuthentication) {
+			t.Errorf("%s: 密钥错误应认证失败, got %v", mac, err)
+		}
+	}
+}
+
+func TestEncryptThenMACKeySeparation(t *testing.T) {
+	key := []byte("1111111111111111")
+	e, err := NewEncryptThenMAC(key, HMACSHA256)
+	if err != nil {
+		t.Fatal(err)
+	}
+	other, _ := NewEncryptThenMAC(key, HMACSM3)
+	ed, _ := e.Seal([]byte("123"), nil)
+	// 密文中记录了 MAC 算法，不能用另一种算法打开
+	if _, err := other.Open(ed, nil); !errors.Is(err, ErrUnsupportedAlgorithm) {
+		t.Errorf("MAC 算法不一致应返回 ErrUnsupportedAlgorithm, got %v", err)
+	}
+	block, _ := aes.NewCipher(key)
+	// 加密密钥由 HKDF 派生，与主密钥不同，直接用主密钥 CBC 解密得不到明文
+	if dd, err := decryptCBC(block, ed[2:18], ed[18:len(ed)-32]); err == nil && string(dd) == "123" {
+		t.Error("加密密钥不应等于主密钥")
+	}
+	if _, err := NewEncryptThenMAC(key, MAC(9)); !errors.Is(err, ErrUnsupportedAlgorithm) {
+		t.Errorf("未知 MAC 算法应返回 ErrUnsupportedAlgorithm, got %v", err)
+	}
+	if _, err := NewEncryptThenMAC(key[:10], HMACSHA256); err == nil {
+		t.Error("主密钥长度错误应返回错误")
+	}
+	if _, err := Decrypt(ed, key); !errors.Is(err, ErrUnknownFormat) {
+		t.Errorf("Decrypt 不应接受 Encrypt-then-MAC 格式, got %v", err)
+	}
+}
+
+// 加密子切片时不能改写调用方缓冲区中子切片之后的数据
+func TestEncryptThenMACSubslice(t *testing.T) {
+	e, _ := NewEncryptThenMAC([]byte("1111111111111111"), HMACSHA256)
+	buf := []byte("abcdefghijklmnopqrstuvwxyz")
+	want := append([]byte(nil), buf...)
+	ed, err := e.Seal(buf[:3], nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(buf, want) {
+		t.Fatalf("缓冲区被改写: %q", buf)
+	}
+	dd, err := e.Open(ed, nil)
+	if err != nil || string(dd) != "abc" {
+		t.Fatalf("%q %v", dd, err)
+	}
+}
@@ -0,0 +1,345 @@
+package gaes
+
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"reflect"
+	"sync/atomic"
+)
+
+// 结构体字段加密：
+//  1、EncryptStruct/DecryptStruct 遍历结构体，加解密带 `crypt:"aes"` 标签的字段，
+//     支持 string、[]byte、*string 及其切片，嵌套结构体、结构体指针、切片和数组会递归处理
+//  2、字段使用 KeyRing 的主密钥加密为信封，string 字段保存文本信封，[]byte 字段保存二进制信封，
+//     轮换密钥后旧数据仍可解密
+//  3、空值不加密，保持数据库中的空字符串/NULL 语义
+//  4、EncryptStruct 不能重复调用，否则字段会被加密两次
+//  5、所有字段处理成功后才写回，任一字段出错时返回错误，结构体保持不变
+//
+//	type User struct {
+//		Name  string
+//		Phone string `crypt:"aes"`
+//	}
+//	err := gaes.EncryptStruct(&user, ring)
+//
+// ORM 模型也可以直接使用 EncryptedString/EncryptedBytes 类型，读写数据库和 JSON 时自动加解密，
+// 使用前需要调用 SetFieldKeyRing 设置密钥环
+
+// cryptTag 字段加密标签名
+const cryptTag = "crypt"
+
+// ErrNotStructPointer 参数不是结构体指针
+var ErrNotStructPointer = errors.New("gaes: 参数必须是非 nil 的结构体指针")
+
+// ErrNoFieldKeyRing 未调用 SetFieldKeyRing
+var ErrNoFieldKeyRing = errors.New("gaes: 未设置字段加密密钥环")
+
+// EncryptStruct 使用 ring 的主密钥加密 v 中带 `crypt:"aes"` 标签的字段，v 必须是结构体指针
+func EncryptStruct(v any, ring *KeyRing) error {
+	return walkStruct(v, func(data []byte, text bool) ([]byte, error) {
+		env, err := ring.Seal(data, nil)
+		if err != nil {
+			return nil, err
+		}
+		if text {
+			return env.MarshalText()
+		}
+		return env.MarshalBinary()
+	})
+}
+
+// DecryptStruct 解密 EncryptStruct 加密的字段
+func DecryptStruct(v any, ring *KeyRing) error {
+	return walkStruct(v, func(data []byte, _ bool) ([]byte, error) {
+		return ring.Decrypt(data, nil)
+	})
+}
+
+// fieldFunc 加密或解密一个字段的值，text 表示字段为字符串
+type fieldFunc func(data []byte, text bool) ([]byte, error)
+
+func walkStruct(v any, fn fieldFunc) error {
+	rv := reflect.ValueOf(v)
+	if rv.Kind() != reflect.Pointer || rv.IsNil() || rv.Elem().Kind() != reflect.Struct {
+		return ErrNotStructPointer
+	}
+	w := &structWalker{fn: fn, seen: make(map[seenPointer]bool)}
+	if err := w.walk(rv.Elem(), ""); err != nil {
+		return err
+	}
+	for _, u := range w.updates {
+		u.field.Set(u.value)
+	}
+	return nil
+}
+
+// structWalker 递归遍历结构体，seen 记录已处理的指针和字段地址，避免循环引用和重复加密
+// 处理结果先记录在 updates 中，全部成功后再写回
+type structWalker struct {
+	fn      fieldFunc
+	seen    map[seenPointer]bool
+	updates []fieldUpdate
+}
+
+// fieldUpdate 待写回的字段值
+type fieldUpdate struct {
+	field reflect.Value
+	value reflect.Value
+}
+
+// seenPointer 指针地址和类型，结构体指针与其第一个字段的指针地址相同，需按类型区分
+type seenPointer struct {
+	addr uintptr
+	typ  reflect.Type
+}
+
+// visit 标记指针已处理，已处理过返回 false
+func (w *structWalker) visit(v reflect.Value) bool {
+	key := seenPointer{v.Pointer(), v.Type()}
+	if w.seen[key] {
+		return false
+	}
+	w.seen[key] = true
+	return true
+}
+
+// visitLeaf 按地址标记带标签的字符串或字节切片，值字段与指向它的指针字段地址相同，只处理一次
+func (w *structWalker) visitLeaf(v reflect.Value) bool {
+	if !v.CanAddr() {
+		return true
+	}
+	return w.visit(v.Addr())
+}
+
+// walk 遍历 v 中的结构体，path 为字段路径，用于错误信息
+func (w *structWalker) walk(v reflect.Value, path string) error {
+	switch v.Kind() {
+	case reflect.Pointer:
+		if v.IsNil() || !w.visit(v) {
+			return nil
+		}
+		return w.walk(v.Elem(), path)
+	case reflect.Interface:
+		if v.IsNil() {
+			return nil
+		}
+		// 接口中的值不可寻址，只处理其中的指针
+		if e := v.Elem(); e.Kind() == reflect.Pointer {
+			return w.walk(e, path)
+		}
+	case reflect.Slice, reflect.Array:
+		for i := 0; i < v.Len(); i++ {
+			if err := w.walk(v.Index(i), fmt.Sprintf("%s[%d]", path, i)); err != nil {
+				return err
+			}
+		}
+	case reflect.Struct:
+		t := v.Type()
+		for i := 0; i < t.NumField(); i++ {
+			f := t.Field(i)
+			name := f.Name
+			if path != "" {
+				name = path + "." + f.Name
+			}
+			tag, tagged := f.Tag.Lookup(cryptTag)
+			if !f.IsExported() {
+				if tagged {
+					return fmt.Errorf("gaes: 字段 %s 未导出，不能加密", name)
+				}
+				continue
+			}
+			if !tagged || tag == "-" {
+				if err := w.walk(v.Field(i), name); err != nil {
+					return err
+				}
+				continue
+			}
+			if tag != "aes" {
+				return fmt.Errorf("gaes: 字段 %s 的加密标签 %q 不支持", name, tag)
+			}
+			if err := w.crypt(v.Field(i), name); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// crypt 加解密带标签的字段
+func (w *structWalker) crypt(v reflect.Value, path string) error {
+	switch {
+	case v.Kind() == reflect.String:
+		if v.Len() == 0 || !w.visitLeaf(v) {
+			return nil
+		}
+		out, err := w.fn([]byte(v.String()), true)
+		if err != nil {
+			return fmt.Errorf("gaes: 字段 %s: %w", path, err)
+		}
+		w.updates = append(w.updates, fieldUpdate{v, reflect.ValueOf(string(out)).Convert(v.Type())})
+	case v.Kind() == reflect.Slice && v.Type().Elem().Kind() == reflect.Uint8:
+		if v.Len() == 0 || !w.visitLeaf(v) {
+			return nil
+		}
+		out, err := w.fn(v.Bytes(), false)
+		if err != nil {
+			return fmt.Errorf("gaes: 字段 %s: %w", path, err)
+		}
+		w.updates = append(w.updates, fieldUpdate{v, reflect.ValueOf(out).Convert(v.Type())})
+	case v.Kind() == reflect.Pointer:
+		// 指向的值按地址去重，多个字段指向同一个值时只加解密一次
+		if v.IsNil() {
+			return nil
+		}
+		return w.crypt(v.Elem(), path)
+	case v.Kind() == reflect.Slice || v.Kind() == reflect.Array:
+		for i := 0; i < v.Len(); i++ {
+			if err := w.crypt(v.Index(i), fmt.Sprintf("%s[%d]", path, i)); err != nil {
+				return err
+			}
+		}
+	default:
+		return fmt.Errorf("gaes: 字段 %s 的类型 %s 不支持加密", path, v.Type())
+	}
+	return nil
+}
+
+// fieldKeyRing EncryptedString/EncryptedBytes 使用的密钥环
+var fieldKeyRing atomic.Pointer[KeyRing]
+
+// SetFieldKeyRing 设置 EncryptedString/EncryptedBytes 使用的密钥环，通常在程序启动时调用一次
+func SetFieldKeyRing(ring *KeyRing) {
+	fieldKeyRing.Store(ring)
+}
+
+func loadFieldKeyRing() (*KeyRing, error) {
+	ring := fieldKeyRing.Load()
+	if ring == nil {
+		return nil, ErrNoFieldKeyRing
+	}
+	return ring, nil
+}
+
+// EncryptedString 写入数据库和 JSON 时自动加密为文本信封、读取时自动解密的字符串，内存中保存明文
+type EncryptedString string
+
+// Value 实现 driver.Valuer，空字符串不加密
+func (s EncryptedString) Value() (driver.Value, error) {
+	if s == "" {
+		return "", nil
+	}
+	text, err := encryptField([]byte(s))
+	if err != nil {
+		return nil, err
+	}
+	return string(text), nil
+}
+
+// Scan 实现 sql.Scanner，支持 string、[]byte 和 NULL
+func (s *EncryptedString) Scan(src any) error {
+	plaintext, err := scanField(src)
+	if err != nil {
+		return err
+	}
+	*s = EncryptedString(plaintext)
+	return nil
+}
+
+// MarshalJSON 输出加密后的 JSON 字符串
+func (s EncryptedString) MarshalJSON() ([]byte, error) {
+	v, err := s.Value()
+	if err != nil {
+		return nil, err
+	}
+	return json.Marshal(v)
+}
+
+// UnmarshalJSON 解析并解密 MarshalJSON 的输出
+func (s *EncryptedString) UnmarshalJSON(data []byte) error {
+	var text string
+	if err := json.Unmarshal(data, &text); err != nil {
+		return err
+	}
+	return s.Scan(text)
+}
+
+// EncryptedBytes 与 EncryptedString 相同，用于二进制字段，JSON 中为 base64 编码的二进制信封
+type EncryptedBytes []byte
+
+// Value 实现 driver.Valuer，写入二进制信封，空值不加密
+func (b EncryptedBytes) Value() (driver.Value, error) {
+	if len(b) == 0 {
+		return []byte(nil), nil
+	}
+	ring, err := loadFieldKeyRing()
+	if err != nil {
+		return nil, err
+	}
+	return ring.Encrypt(b, nil)
+}
+
+// Scan 实现 sql.Scanner，支持 string、[]byte 和 NULL
+func (b *EncryptedBytes) Scan(src any) error {
+	plaintext, err := scanField(src)
+	if err != nil {
+		return err
+	}
+	*b = plaintext
+	return nil
+}
+
+// MarshalJSON 输出 base64 编码的二进制信封
+func (b EncryptedBytes) MarshalJSON() ([]byte, error) {
+	v, err := b.Value()
+	if err != nil {
+		return nil, err
+	}
+	return json.Marshal(v)
+}
+
+// UnmarshalJSON 解析并解密 MarshalJSON 的输出
+func (b *EncryptedBytes) UnmarshalJSON(data []byte) error {
+	var raw []byte
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return err
+	}
+	return b.Scan(raw)
+}
+
+// encryptField 使用字段密钥环加密为文本信封
+func encryptField(data []byte) ([]byte, error) {
+	ring, err := loadFieldKeyRing()
+	if err != nil {
+		return nil, err
+	}
+	env, err := ring.Seal(data, nil)
+	if err != nil {
+		return nil, err
+	}
+	return env.MarshalText()
+}
+
+// scanField 解密数据库中读出的信封，空值返回 nil
+func scanField(src any) ([]byte, error) {
+	var data []byte
+	switch v := src.(type) {
+	case nil:
+		return nil, nil
+	case string:
+		data = []byte(v)
+	case []byte:
+		data = v
+	default:
+		return nil, fmt.Errorf("gaes: 不支持从 %T 读取加密字段", src)
+	}
+	if len(data) == 0 {
+		return nil, nil
+	}
+	ring, err := loadFieldKeyRing()
+	if err != nil {
+		return nil, err
+	}
+	return ring.Decrypt(data, nil)
+}
@@ -0,0 +1,237 @@
+package gaes
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"strings"
+	"testing"
+)
+
+type testAddress struct {
+	City   string
+	Street string `crypt:"aes"`
+}
+
+type testUser struct {
+	Name     string
+	Phone    string   `crypt:"aes"`
+	IDCard   *string  `crypt:"aes"`
+	Avatar   []byte   `crypt:"aes"`
+	Emails   []string `crypt:"aes"`
+	Empty    string   `crypt:"aes"`
+	Skip     string   `crypt:"-"`
+	Home     testAddress
+	Offices  []*testAddress
+	internal string
+}
+
+func newTestRing(t *testing.T) *KeyRing {
+	t.Helper()
+	ring := NewKeyRing()
+	if err := ring.Rotate("k1", []byte("11111111111111111111111111111111")); err != nil {
+		t.Fatal(err)
+	}
+	return ring
+}
+
+func TestEncryptStruct(t *testing.T) {
+	ring := newTestRing(t)
+	id := "110101199001011234"
+	office := &testAddress{City: "上海", Street: "世纪大道 1 号"}
+	u := testUser{
+		Name:    "张三",
+		Phone:   "13800138000",
+		IDCard:  &id,
+		Avatar:  []byte{1, 2, 3},
+		Emails:  []string{"a@example.com", "b@example.com"},
+		Skip:    "plain",
+		Home:    testAddress{City: "北京", Street: "长安街 1 号"},
+		Offices: []*testAddress{office, office},
+	}
+	if err := EncryptStruct(&u, ring); err != nil {
+		t.Fatal(err)
+	}
+	if u.Name != "张三" || u.Skip != "plain" || u.Home.City != "北京" || u.Empty != "" {
+		t.Errorf("未标记的字段和空值不应加密: %+v", u)
+	}
+	for _, s := range []string{u.Phone, id, u.Emails[0], u.Emails[1], u.Home.Street, office.Street} {
+		if !strings.HasPrefix(s, "2.aes-gcm.") {
+			t.Errorf("字段应加密为文本信封: %q", s)
+		}
+	}
+	if u.Avatar[0] != formatEnvelope {
+		t.Errorf("[]byte 字段应加密为二进制信封: %x", u.Avatar)
+	}
+
+	// 轮换密钥后旧数据仍可解密
+	if err := ring.Rotate("k2", []byte("22222222222222222222222222222222")); err != nil {
+		t.Fatal(err)
+	}
+	if err := DecryptStruct(&u, ring); err != nil {
+		t.Fatal(err)
+	}
+	if u.Phone != "13800138000" || id != "110101199001011234" || !bytes.Equal(u.Avatar, []byte{1, 2, 3}) ||
+		u.Emails[1] != "b@example.com" || u.Home.Street != "长安街 1 号" || office.Street != "世纪大道 1 号" {
+		t.Errorf("解密结果不一致: %+v", u)
+	}
+}
+
+// 多个字段指向同一个值时只加解密一次
+func TestEncryptStructSharedPointer(t *testing.T) {
+	ring := newTestRing(t)
+	phone, avatar := "13800138000", []byte{1, 2, 3}
+	office := &testAddress{City: "上海", Street: "世纪大道 1 号"}
+	v := struct {
+		Phone   *string `crypt:"aes"`
+		Mobile  *string `crypt:"aes"`
+		Avatar  *[]byte `crypt:"aes"`
+		Photo   *[]byte `crypt:"aes"`
+		Office  *testAddress
+		City    *string `crypt:"aes"` // 与 Office 地址相同，类型不同
+		Offices []*testAddress
+		Home    string  `crypt:"aes"`
+		HomePtr *string `crypt:"aes"` // 指向同一结构体中的值字段
+	}{&phone, &phone, &avatar, &avatar, office, &office.City, []*testAddress{office}, "北京", nil}
+	v.HomePtr = &v.Home
+	if err := EncryptStruct(&v, ring); err != nil {
+		t.Fatal(err)
+	}
+	for _, s := range []string{phone, office.City, office.Street, v.Home} {
+		if !strings.HasPrefix(s, "2.aes-gcm.") {
+			t.Errorf("字段应加密为文本信封: %q", s)
+		}
+	}
+	// 只加密一次时单独解密一次即可得到明文
+	once := struct {
+		Phone  string `crypt:"aes"`
+		Avatar []byte `crypt:"aes"`
+		City   string `crypt:"aes"`
+		Home   string `crypt:"aes"`
+	}{phone, avatar, office.City, v.Home}
+	if err := DecryptStruct(&once, ring); err != nil {
+		t.Fatal(err)
+	}
+	if once.Phone != "13800138000" || !bytes.Equal(once.Avatar, []byte{1, 2, 3}) || once.City != "上海" || once.Home != "北京" {
+		t.Errorf("共享的值被重复加密: %+v", once)
+	}
+	if err := DecryptStruct(&v, ring); err != nil {
+		t.Fatal(err)
+	}
+	if phone != "13800138000" || !bytes.Equal(avatar, []byte{1, 2, 3}) || office.City != "上海" || office.Street != "世纪大道 1 号" || v.Home != "北京" {
+		t.Errorf("解密结果不一致: %q %x %+v %q", phone, avatar, office, v.Home)
+	}
+}
+
+// 后面的字段出错时，前面已处理的字段不写回
+func TestEncryptStructPartialFailure(t *testing.T) {
+	ring := newTestRing(t)
+	bad := struct {
+		Phone string `crypt:"aes"`
+		Age   int    `crypt:"aes"`
+	}{Phone: "13800138000", Age: 18}
+	if err := EncryptStruct(&bad, ring); err == nil {
+		t.Fatal("不支持的字段类型应返回错误")
+	}
+	if bad.Phone != "13800138000" {
+		t.Errorf("出错时结构体不应被修改: %q", bad.Phone)
+	}
+
+	v := struct {
+		Phone string `crypt:"aes"`
+		Email string `crypt:"aes"`
+	}{Phone: "13800138000", Email: "a@example.com"}
+	if err := EncryptStruct(&v, ring); err != nil {
+		t.Fatal(err)
+	}
+	phone := v.Phone
+	v.Email = "not an envelope"
+	if err := DecryptStruct(&v, ring); err == nil {
+		t.Fatal("无效信封应返回错误")
+	}
+	if v.Phone != phone {
+		t.Errorf("出错时结构体不应被修改: %q", v.Phone)
+	}
+}
+
+func TestEncryptStructInvalid(t *testing.T) {
+	ring := newTestRing(t)
+	var u testUser
+	for _, v := range []any{u, (*testUser)(nil), new(string), nil} {
+		if err := EncryptStruct(v, ring); !errors.Is(err, ErrNotStructPointer) {
+			t.Errorf("%T: 应返回 ErrNotStructPointer, got %v", v, err)
+		}
+	}
+	if err := EncryptStruct(&struct {
+		Age int `crypt:"aes"`
+	}{Age: 1}, ring); err == nil {
+		t.Error("不支持的字段类型应返回错误")
+	}
+	if err := EncryptStruct(&struct {
+		Phone string `crypt:"des"`
+	}{}, ring); err == nil {
+		t.Error("不支持的标签应返回错误")
+	}
+	if err := EncryptStruct(&struct {
+		phone string `crypt:"aes"`
+	}{}, ring); err == nil {
+		t.Error("未导出的加密字段应返回错误")
+	}
+	bad := struct {
+		Phone string `crypt:"aes"`
+	}{Phone: "13800138000"}
+	if err := DecryptStruct(&bad, ring); err == nil || !strings.Contains(err.Error(), "Phone") {
+		t.Errorf("解密失败应返回包含字段名的错误, got %v", err)
+	}
+}
+
+func TestEncryptedString(t *testing.T) {
+	SetFieldKeyRing(nil)
+	if _, err := EncryptedString("123").Value(); !errors.Is(err, ErrNoFieldKeyRing) {
+		t.Errorf("未设置密钥环应返回 ErrNoFieldKeyRing, got %v", err)
+	}
+	SetFieldKeyRing(newTestRing(t))
+	defer SetFieldKeyRing(nil)
+
+	v, err := EncryptedString("13800138000").Value()
+	if err != nil {
+		t.Fatal(err)
+	}
+	var s EncryptedString
+	if err := s.Scan([]byte(v.(string))); err != nil || s != "13800138000" {
+		t.Errorf("Scan 结果不一致: %q %v", s, err)
+	}
+	if err := s.Scan(nil); err != nil || s != "" {
+		t.Errorf("NULL 应得到空字符串: %q %v", s, err)
+	}
+	if err := s.Scan(1); err == nil {
+		t.Error("不支持的类型应返回错误")
+	}
+
+	type model struct {
+		Phone  EncryptedString
+		Avatar EncryptedBytes
+	}
+	data, err := json.Marshal(model{Phone: "13800138000", Avatar: EncryptedBytes{1, 2, 3}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if bytes.Contains(data, []byte("13800138000")) {
+		t.Errorf("JSON 中不应出现明文: %s", data)
+	}
+	var m model
+	if err := json.Unmarshal(data, &m); err != nil {
+		t.Fatal(err)
+	}
+	if m.Phone != "13800138000" || !bytes.Equal(m.Avatar, []byte{1, 2, 3}) {
+		t.Errorf("JSON 解密结果不一致: %+v", m)
+	}
+	b, err := EncryptedBytes{1, 2, 3}.Value()
+	if err != nil {
+		t.Fatal(err)
+	}
+	var eb EncryptedBytes
+	if err := eb.Scan(b); err != nil || !bytes.Equal(eb, []byte{1, 2, 3}) {
+		t.Errorf("EncryptedBytes Scan 结果不一致: %x %v", eb, err)
+	}
+}
@@ -0,0 +1,19 @@
+package gaes
+
+// 密文首字节为格式版本号，用于区分不同的密文格式
+const (
+	// formatCBC AES-CBC 随机 IV 格式：版本号 || IV || 密文
+	formatCBC byte = 0x01
+	// formatEnvelope 自描述信封格式，见 Envelope
+	formatEnvelope byte = 0x02
+	// formatStream 流式分段加密格式，见 NewEncryptWriter
+	formatStream byte = 0x03
+	// formatPassword 基于口令的加密格式，见 EncryptWithPassword
+	formatPassword byte = 0x04
+	// formatWrapped 信封加密格式，密文中包含包装后的数据密钥，见 EnvelopeEncrypt
+	formatWrapped byte = 0x05
+	// formatToken 带过期时间的加密令牌，见 Token
+	formatToken byte = 0x06
+	// formatEtM AES-CBC + HMAC 先加密后认证格式，见 EncryptThenMAC
+	formatEtM byte = 0x07
+)
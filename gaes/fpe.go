@@ -0,0 +1,320 @@
+package gaes
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/subtle"
+	"errors"
+	"math"
+	"math/big"
+	"strings"
+)
+
+// 保留格式加密（NIST SP 800-38G Rev.1），用于银行卡号、身份证号等需要保持长度和字符集的字段：
+//  1、FF1 支持任意长度的 tweak，字符串长度 2 到 2^31-1（标准上限为 2^32-1，受 32 位平台 int 限制）
+//  2、FF3-1 使用 7 字节（56 比特）tweak，最大长度受 radix 限制，十进制最多 56 位
+//  3、字符集为 "0123456789abcdefghijklmnopqrstuvwxyz" 的前 radix 个字符，十进制即数字串
+//  4、radix^长度 必须不小于 1000000，十进制至少 6 位
+// 保留格式加密是确定性的，相同密钥、tweak 下相同明文得到相同密文，不提供完整性保护。
+// tweak 可以使用字段名、卡 BIN 等不需要保密的上下文，不同 tweak 得到不同的置换。
+//
+//	ff1, err := gaes.NewFF1(key, 10)
+//	token, err := ff1.Encrypt("6222021234567890123", []byte("card"))
+
+// fpeAlphabet 保留格式加密的字符集
+const fpeAlphabet = "0123456789abcdefghijklmnopqrstuvwxyz"
+
+// fpeMinDomain radix^长度 的最小值
+const fpeMinDomain = 1000000
+
+// ErrInvalidRadix radix 超出范围
+var ErrInvalidRadix = errors.New("gaes: radix 必须在 2 到 36 之间")
+
+// ErrInvalidNumeral 字符串包含字符集之外的字符
+var ErrInvalidNumeral = errors.New("gaes: 字符串包含字符集之外的字符")
+
+// ErrInvalidLength 字符串长度超出保留格式加密允许的范围
+var ErrInvalidLength = errors.New("gaes: 字符串长度超出允许范围")
+
+// ErrInvalidTweak tweak 长度错误
+var ErrInvalidTweak = errors.New("gaes: tweak 长度错误")
+
+// fpe FF1 与 FF3-1 共用的字符集和长度校验
+type fpe struct {
+	radix  int
+	minLen int
+	maxLen int
+}
+
+func newFPE(radix, maxLen int) (fpe, error) {
+	if radix < 2 || radix > len(fpeAlphabet) {
+		return fpe{}, ErrInvalidRadix
+	}
+	minLen, domain := 2, radix*radix
+	for domain < fpeMinDomain {
+		minLen++
+		domain *= radix
+	}
+	return fpe{radix: radix, minLen: minLen, maxLen: maxLen}, nil
+}
+
+// check 校验 x 的长度和字符
+func (f fpe) check(x string) error {
+	if len(x) < f.minLen || len(x) > f.maxLen {
+		return ErrInvalidLength
+	}
+	for i := 0; i < len(x); i++ {
+		if d := strings.IndexByte(fpeAlphabet, x[i]); d < 0 || d >= f.radix {
+			return ErrInvalidNumeral
+		}
+	}
+	return nil
+}
+
+// num 将 radix 进制字符串转换为整数，x 已通过 check
+func (f fpe) num(x string) *big.Int {
+	n, _ := new(big.Int).SetString(x, f.radix)
+	return n
+}
+
+// str 将 n 转换为 m 位 radix 进制字符串，不足 m 位时左侧补 0
+func (f fpe) str(n *big.Int, m int) string {
+	s := n.Text(f.radix)
+	if len(s) < m {
+		s = strings.Repeat("0", m-len(s)) + s
+	}
+	return s
+}
+
+// pow 返回 radix^m
+func (f fpe) pow(m int) *big.Int {
+	return new(big.Int).Exp(big.NewInt(int64(f.radix)), big.NewInt(int64(m)), nil)
+}
+
+// FF1 NIST SP 800-38G FF1 保留格式加密，可并发使用
+type FF1 struct {
+	fpe
+	block cipher.Block
+}
+
+// NewFF1 创建 FF1，key 为 16、24、32 字节 AES 密钥，radix 为字符集大小
+func NewFF1(key []byte, radix int) (*FF1, error) {
+	f, err := newFPE(radix, math.MaxInt32)
+	if err != nil {
+		return nil, err
+	}
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	return &FF1{fpe: f, block: block}, nil
+}
+
+// Encrypt 加密 radix 进制字符串 x，密文与 x 长度、字符集相同
+func (f *FF1) Encrypt(x string, tweak []byte) (string, error) {
+	return f.crypt(x, tweak, true)
+}
+
+// Decrypt 解密 Encrypt 的输出，tweak 必须与加密时一致
+func (f *FF1) Decrypt(x string, tweak []byte) (string, error) {
+	return f.crypt(x, tweak, false)
+}
+
+// crypt SP 800-38G 算法 7、8
+func (f *FF1) crypt(x string, tweak []byte, encrypt bool) (string, error) {
+	if err := f.check(x); err != nil {
+		return "", err
+	}
+	n, t := len(x), len(tweak)
+	u := n / 2
+	v := n - u
+	a, b := x[:u], x[u:]
+	// b = ⌈⌈v·log2(radix)⌉/8⌉，即 radix^v - 1 的字节数
+	bLen := (new(big.Int).Sub(f.pow(v), big.NewInt(1)).BitLen() + 7) / 8
+	d := 4*((bLen+3)/4) + 4
+
+	p := []byte{1, 2, 1, byte(f.radix >> 16), byte(f.radix >> 8), byte(f.radix), 10, byte(u),
+		byte(n >> 24), byte(n >> 16), byte(n >> 8), byte(n),
+		byte(t >> 24), byte(t >> 16), byte(t >> 8), byte(t)}
+	pad := (16 - (t+bLen+1)%16) % 16
+	q := make([]byte, t+pad+1+bLen)
+	copy(q, tweak)
+	s := make([]byte, (d+15)/16*16)
+	r := make([]byte, 16)
+	modU, modV := f.pow(u), f.pow(v)
+	y, c := new(big.Int), new(big.Int)
+
+	for j := 0; j < 10; j++ {
+		i := j
+		if !encrypt {
+			i = 9 - j
+		}
+		// Q = T || 0^pad || [i] || [NUM(B)]^b，解密时使用 A
+		q[t+pad] = byte(i)
+		in := b
+		if !encrypt {
+			in = a
+		}
+		f.num(in).FillBytes(q[t+pad+1:])
+		// R = PRF(P || Q)，即零 IV 的 CBC-MAC
+		f.block.Encrypt(r, p)
+		for k := 0; k < len(q); k += 16 {
+			subtle.XORBytes(r, r, q[k:k+16])
+			f.block.Encrypt(r, r)
+		}
+		// S = R || CIPH(R ⊕ [1]^16) || CIPH(R ⊕ [2]^16) ... 的前 d 字节
+		copy(s, r)
+		for k := 1; k < len(s)/16; k++ {
+			blk := s[16*k : 16*k+16]
+			copy(blk, r)
+			for l, bt := 0, k; bt > 0 && l < 16; l++ {
+				blk[15-l] ^= byte(bt)
+				bt >>= 8
+			}
+			f.block.Encrypt(blk, blk)
+		}
+		y.SetBytes(s[:d])
+		m, mod := u, modU
+		if i%2 == 1 {
+			m, mod = v, modV
+		}
+		if encrypt {
+			c.Add(f.num(a), y)
+			c.Mod(c, mod)
+			a, b = b, f.str(c, m)
+		} else {
+			c.Sub(f.num(b), y)
+			c.Mod(c, mod)
+			a, b = f.str(c, m), a
+		}
+	}
+	return a + b, nil
+}
+
+// ff3TweakSize FF3-1 tweak 长度
+const ff3TweakSize = 7
+
+// FF3 NIST SP 800-38G Rev.1 FF3-1 保留格式加密（56 比特 tweak），可并发使用
+type FF3 struct {
+	fpe
+	block cipher.Block
+}
+
+// NewFF3 创建 FF3-1，key 为 16、24、32 字节 AES 密钥，radix 为字符集大小
+func NewFF3(key []byte, radix int) (*FF3, error) {
+	// 最大长度 2·⌊log_radix(2^96)⌋
+	half, domain, limit := 0, big.NewInt(int64(radix)), new(big.Int).Lsh(big.NewInt(1), 96)
+	for radix >= 2 && domain.Cmp(limit) <= 0 {
+		half++
+		domain.Mul(domain, big.NewInt(int64(radix)))
+	}
+	f, err := newFPE(radix, 2*half)
+	if err != nil {
+		return nil, err
+	}
+	switch len(key) {
+	case 16, 24, 32:
+	default:
+		return nil, aes.KeySizeError(len(key))
+	}
+	// FF3 使用字节逆序后的密钥
+	rev := make([]byte, len(key))
+	for i := range key {
+		rev[i] = key[len(key)-1-i]
+	}
+	block, err := aes.NewCipher(rev)
+	if err != nil {
+		return nil, err
+	}
+	return &FF3{fpe: f, block: block}, nil
+}
+
+// Encrypt 加密 radix 进制字符串 x，tweak 必须为 7 字节
+func (f *FF3) Encrypt(x string, tweak []byte) (string, error) {
+	tl, tr, err := ff3SplitTweak(tweak)
+	if err != nil {
+		return "", err
+	}
+	return f.crypt(x, tl, tr, true)
+}
+
+// Decrypt 解密 Encrypt 的输出，tweak 必须与加密时一致
+func (f *FF3) Decrypt(x string, tweak []byte) (string, error) {
+	tl, tr, err := ff3SplitTweak(tweak)
+	if err != nil {
+		return "", err
+	}
+	return f.crypt(x, tl, tr, false)
+}
+
+// ff3SplitTweak FF3-1 将 56 比特 tweak 拆分为 TL = T[0..27] || 0^4，TR = T[32..55] || T[28..31] || 0^4
+func ff3SplitTweak(tweak []byte) (tl, tr [4]byte, err error) {
+	if len(tweak) != ff3TweakSize {
+		return tl, tr, ErrInvalidTweak
+	}
+	tl = [4]byte{tweak[0], tweak[1], tweak[2], tweak[3] & 0xf0}
+	tr = [4]byte{tweak[4], tweak[5], tweak[6], tweak[3] << 4}
+	return tl, tr, nil
+}
+
+// crypt SP 800-38G 算法 9、10，tl/tr 为拆分后的 tweak
+func (f *FF3) crypt(x string, tl, tr [4]byte, encrypt bool) (string, error) {
+	if err := f.check(x); err != nil {
+		return "", err
+	}
+	n := len(x)
+	v := n / 2
+	u := n - v
+	// FF3 按逆序字符串计算数值
+	a, b := reverse(x[:u]), reverse(x[u:])
+	modU, modV := f.pow(u), f.pow(v)
+	p := make([]byte, 16)
+	y, c := new(big.Int), new(big.Int)
+
+	for j := 0; j < 8; j++ {
+		i := j
+		if !encrypt {
+			i = 7 - j
+		}
+		m, mod, w := u, modU, tr
+		if i%2 == 1 {
+			m, mod, w = v, modV, tl
+		}
+		// P = W ⊕ [i]^4 || [NUM(REV(B))]^12，解密时使用 A
+		copy(p, w[:])
+		p[3] ^= byte(i)
+		in := b
+		if !encrypt {
+			in = a
+		}
+		f.num(in).FillBytes(p[4:])
+		// S = REVB(CIPH_REVB(K)(REVB(P)))
+		reverseBytes(p)
+		f.block.Encrypt(p, p)
+		reverseBytes(p)
+		y.SetBytes(p)
+		if encrypt {
+			c.Add(f.num(a), y)
+			c.Mod(c, mod)
+			a, b = b, f.str(c, m)
+		} else {
+			c.Sub(f.num(b), y)
+			c.Mod(c, mod)
+			a, b = f.str(c, m), a
+		}
+	}
+	return reverse(a) + reverse(b), nil
+}
+
+// reverse 返回字节逆序的字符串，字符集均为 ASCII
+func reverse(s string) string {
+	b := []byte(s)
+	reverseBytes(b)
+	return string(b)
+}
+
+func reverseBytes(b []byte) {
+	for i, j := 0, len(b)-1; i < j; i, j = i+1, j-1 {
+		b[i], b[j] = b[j], b[i]
+	}
+}
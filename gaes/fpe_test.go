@@ -0,0 +1,186 @@
+package gaes
+
+import (
+	"errors"
+	"os"
+	"os/exec"
+	"strings"
+	"testing"
+)
+
+// NIST SP 800-38G FF1 示例向量
+func TestFF1Vectors(t *testing.T) {
+	const (
+		key128 = "2b7e151628aed2a6abf7158809cf4f3c"
+		key192 = key128 + "ef4359d8d580aa4f"
+		key256 = key192 + "7f036d6f04fc6a94"
+		tweak  = "39383736353433323130"
+		tweak3 = "3737373770717273373737"
+	)
+	cases := []struct {
+		key, tweak string
+		radix      int
+		pt, ct     string
+	}{
+		{key128, "", 10, "0123456789", "2433477484"},
+		{key128, tweak, 10, "0123456789", "6124200773"},
+		{key128, tweak3, 36, "0123456789abcdefghi", "a9tv40mll9kdu509eum"},
+		{key192, "", 10, "0123456789", "2830668132"},
+		{key192, tweak, 10, "0123456789", "2496655549"},
+		{key192, tweak3, 36, "0123456789abcdefghi", "xbj3kv35jrawxv32ysr"},
+		{key256, "", 10, "0123456789", "6657667009"},
+		{key256, tweak, 10, "0123456789", "1001623463"},
+		{key256, tweak3, 36, "0123456789abcdefghi", "xs8a0azh2avyalyzuwd"},
+	}
+	for i, c := range cases {
+		f, err := NewFF1(mustHex(c.key), c.radix)
+		if err != nil {
+			t.Fatal(err)
+		}
+		ct, err := f.Encrypt(c.pt, mustHex(c.tweak))
+		if err != nil {
+			t.Errorf("sample %d: %v", i+1, err)
+			continue
+		}
+		if ct != c.ct {
+			t.Errorf("sample %d: got %s, want %s", i+1, ct, c.ct)
+		}
+		if pt, err := f.Decrypt(ct, mustHex(c.tweak)); err != nil || pt != c.pt {
+			t.Errorf("sample %d: 解密得到 %s %v", i+1, pt, err)
+		}
+	}
+}
+
+// NIST FF3 示例向量（64 比特 tweak），用于校验 FF3-1 与 FF3 共用的轮函数
+func TestFF3Vectors(t *testing.T) {
+	cases := []struct {
+		key, tweak string
+		radix      int
+		pt, ct     string
+	}{
+		{"ef4359d8d580aa4f7f036d6f04fc6a94", "d8e7920afa330a73", 10, "890121234567890000", "750918814058654607"},
+		{"ef4359d8d580aa4f7f036d6f04fc6a94", "9a768a92f60e12d8", 10, "890121234567890000", "018989839189395384"},
+		{"ef4359d8d580aa4f7f036d6f04fc6a94", "d8e7920afa330a73", 10, "89012123456789000000789000000", "48598367162252569629397416226"},
+		{"ef4359d8d580aa4f7f036d6f04fc6a94", "0000000000000000", 10, "89012123456789000000789000000", "34695224821734535122613701434"},
+		{"ef4359d8d580aa4f7f036d6f04fc6a94", "9a768a92f60e12d8", 26, "0123456789abcdefghi", "g2pk40i992fn20cjakb"},
+	}
+	for i, c := range cases {
+		f, err := NewFF3(mustHex(c.key), c.radix)
+		if err != nil {
+			t.Fatal(err)
+		}
+		var tl, tr [4]byte
+		tweak := mustHex(c.tweak)
+		copy(tl[:], tweak[:4])
+		copy(tr[:], tweak[4:])
+		ct, err := f.crypt(c.pt, tl, tr, true)
+		if err != nil {
+			t.Errorf("sample %d: %v", i+1, err)
+			continue
+		}
+		if ct != c.ct {
+			t.Errorf("sample %d: got %s, want %s", i+1, ct, c.ct)
+		}
+		if pt, err := f.crypt(ct, tl, tr, false); err != nil || pt != c.pt {
+			t.Errorf("sample %d: 解密得到 %s %v", i+1, pt, err)
+		}
+	}
+}
+
+// FF3-1 56 比特 tweak 向量
+// NIST ACVP AES-FF3-1 测试向量（radix 10，AES-128），
+// 来源 https://github.com/usnistgov/ACVP-Server 的 ACVP-AES-FF3-1 internalProjection.json
+func TestFF3_1(t *testing.T) {
+	vectors := []struct {
+		key, tweak, pt, ct string
+	}{
+		{"2de79d232df5585d68ce47882ae256d6", "cbd09280979564", "3992520240", "8901801106"},
+		{"01c63017111438f7fc8e24eb16c71ab5", "c4e822dcd09f27",
+			"60761757463116869318437658042297305934914824457484538562",
+			"35637144092473838892796702739628394376915177448290847293"},
+	}
+	for i, v := range vectors {
+		f, err := NewFF3(mustHex(v.key), 10)
+		if err != nil {
+			t.Fatal(err)
+		}
+		tweak := mustHex(v.tweak)
+		ct, err := f.Encrypt(v.pt, tweak)
+		if err != nil || ct != v.ct {
+			t.Errorf("vector %d: 加密得到 %s %v", i, ct, err)
+		}
+		if pt, err := f.Decrypt(v.ct, tweak); err != nil || pt != v.pt {
+			t.Errorf("vector %d: 解密得到 %s %v", i, pt, err)
+		}
+	}
+	f, _ := NewFF3(mustHex(vectors[0].key), 10)
+	if _, err := f.Encrypt("3992520240", mustHex("cbd0928097956400")); !errors.Is(err, ErrInvalidTweak) {
+		t.Errorf("tweak 不是 7 字节应返回 ErrInvalidTweak, got %v", err)
+	}
+}
+
+func TestFPEFormat(t *testing.T) {
+	key := []byte("1111111111111111")
+	ff1, _ := NewFF1(key, 10)
+	ff3, _ := NewFF3(key, 10)
+	card := "6222021234567890123"
+	for name, c := range map[string]interface {
+		Encrypt(string, []byte) (string, error)
+		Decrypt(string, []byte) (string, error)
+	}{"FF1": ff1, "FF3-1": ff3} {
+		tweak := []byte("card:v1")
+		ct, err := c.Encrypt(card, tweak)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(ct) != len(card) || ct == card {
+			t.Errorf("%s: 密文应保持长度且不同于明文: %s", name, ct)
+		}
+		if ff1.check(ct) != nil {
+			t.Errorf("%s: 密文应只包含数字: %s", name, ct)
+		}
+		if again, _ := c.Encrypt(card, tweak); again != ct {
+			t.Errorf("%s: 相同 tweak 应得到相同密文", name)
+		}
+		if other, _ := c.Encrypt(card, []byte("card:v2")); other == ct {
+			t.Errorf("%s: 不同 tweak 应得到不同密文", name)
+		}
+		if pt, err := c.Decrypt(ct, tweak); err != nil || pt != card {
+			t.Errorf("%s: 解密得到 %s %v", name, pt, err)
+		}
+		if _, err := c.Encrypt("12345", tweak); !errors.Is(err, ErrInvalidLength) {
+			t.Errorf("%s: 十进制少于 6 位应返回 ErrInvalidLength, got %v", name, err)
+		}
+		if _, err := c.Encrypt("62220212345678a", tweak); !errors.Is(err, ErrInvalidNumeral) {
+			t.Errorf("%s: 非数字字符应返回 ErrInvalidNumeral, got %v", name, err)
+		}
+	}
+	// 十进制 FF3-1 最多 56 位
+	if _, err := ff3.Encrypt(strings.Repeat("1", 57), []byte("1234567")); !errors.Is(err, ErrInvalidLength) {
+		t.Errorf("超过最大长度应返回 ErrInvalidLength, got %v", err)
+	}
+	if _, err := NewFF1(key, 37); !errors.Is(err, ErrInvalidRadix) {
+		t.Errorf("radix 超出范围应返回 ErrInvalidRadix, got %v", err)
+	}
+	if _, err := NewFF3(key[:10], 10); err == nil {
+		t.Error("密钥长度错误应返回错误")
+	}
+}
+
+// 32 位平台上 int 只有 32 位，长度上限等常量不能超出 int 范围
+func TestBuild32Bit(t *testing.T) {
+	if testing.Short() {
+		t.Skip("跳过交叉编译")
+	}
+	gobin, err := exec.LookPath("go")
+	if err != nil {
+		t.Skip("未找到 go 命令")
+	}
+	for _, arch := range []string{"386", "arm"} {
+		cmd := exec.Command(gobin, "build", "../...")
+		cmd.Env = append(os.Environ(), "GOOS=linux", "GOARCH="+arch, "CGO_ENABLED=0")
+		if out, err := cmd.CombinedOutput(); err != nil {
+			t.Errorf("GOARCH=%s 编译失败: %v\n%s", arch, err, out)
+		}
+	}
+}
@@ -0,0 +1,60 @@
+package gaes
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"errors"
+	"io"
+)
+
+// AES-GCM 认证加密：
+//  1、每次加密随机生成 12 字节 nonce
+//  2、输出格式为 nonce || 密文 || 16 字节认证标签
+//  3、additionalData 为附加认证数据，不加密但参与认证，解密时必须一致
+// 密文或附加数据被篡改时解密返回 ErrAuthentication
+
+// ErrAuthentication 认证失败，密文、标签或附加数据被篡改，或密钥错误
+var ErrAuthentication = errors.New("gaes: 认证失败，数据被篡改或密钥错误")
+
+// ErrCiphertextTooShort 密文长度不足
+var ErrCiphertextTooShort = errors.New("gaes: 密文长度不足")
+
+// newGCM 创建 AES-GCM 实例
+func newGCM(key []byte) (cipher.AEAD, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
+
+// SealGCM 使用 AES-GCM 加密并认证 plaintext，返回 nonce || 密文 || 标签
+func SealGCM(plaintext, key, additionalData []byte) ([]byte, error) {
+	aead, err := newGCM(key)
+	if err != nil {
+		return nil, err
+	}
+	nonce := make([]byte, aead.NonceSize(), aead.NonceSize()+len(plaintext)+aead.Overhead())
+	if _, err := io.ReadFull(rand.Reader, nonce); err != nil {
+		return nil, err
+	}
+	return aead.Seal(nonce, nonce, plaintext, additionalData), nil
+}
+
+// OpenGCM 校验并解密 SealGCM 的输出，认证失败返回 ErrAuthentication
+func OpenGCM(ciphertext, key, additionalData []byte) ([]byte, error) {
+	aead, err := newGCM(key)
+	if err != nil {
+		return nil, err
+	}
+	nonceSize := aead.NonceSize()
+	if len(ciphertext) < nonceSize+aead.Overhead() {
+		return nil, ErrCiphertextTooShort
+	}
+	plaintext, err := aead.Open(nil, ciphertext[:nonceSize], ciphertext[nonceSize:], additionalData)
+	if err != nil {
+		return nil, ErrAuthentication
+	}
+	return plaintext, nil
+}
@@ -0,0 +1,48 @@
+package gaes
+
+import (
+	"bytes"
+	"errors"
+	"testing"
+)
+
+func TestGCM(t *testing.T) {
+	data := []byte("123")
+	key := []byte("1111111111111111")
+	ad := []byte("token")
+	ed, err := SealGCM(data, key, ad)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	dd, err := OpenGCM(ed, key, ad)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	if !bytes.Equal(dd, data) {
+		t.Errorf("解密结果不一致: %q", dd)
+	}
+}
+
+func TestGCMTampered(t *testing.T) {
+	key := []byte("11111111111111111111111111111111")
+	ed, err := SealGCM([]byte("service token"), key, nil)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	for i := range ed {
+		bad := append([]byte(nil), ed...)
+		bad[i] ^= 0x01
+		if _, err := OpenGCM(bad, key, nil); !errors.Is(err, ErrAuthentication) {
+			t.Errorf("篡改第 %d 字节后应认证失败, got %v", i, err)
+		}
+	}
+	if _, err := OpenGCM(ed, key, []byte("other")); !errors.Is(err, ErrAuthentication) {
+		t.Errorf("附加数据不一致应认证失败, got %v", err)
+	}
+	if _, err := OpenGCM(ed[:10], key, nil); !errors.Is(err, ErrCiphertextTooShort) {
+		t.Errorf("截断密文应返回 ErrCiphertextTooShort, got %v", err)
+	}
+}
@@ -0,0 +1,17 @@
+package gaes
+
+import (
+	"hash"
+	"io"
+
+	"golang.org/x/crypto/hkdf"
+)
+
+// hkdfKey 使用 HKDF 从 secret 派生 length 字节子密钥
+func hkdfKey(h func() hash.Hash, secret, salt []byte, info string, length int) ([]byte, error) {
+	key := make([]byte, length)
+	if _, err := io.ReadFull(hkdf.New(h, secret, salt, []byte(info)), key); err != nil {
+		return nil, err
+	}
+	return key, nil
+}
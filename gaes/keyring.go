@@ -0,0 +1,169 @@
+package gaes
+
+import (
+	"bytes"
+	"crypto/aes"
+	"errors"
+	"fmt"
+	"sync"
+)
+
+// KeyRing 按密钥 ID 保存多把密钥，主密钥用于加密，解密时根据信封中的密钥 ID 选择密钥
+// 密钥轮换时 Rotate 新密钥即可，旧密钥继续保留用于解密历史数据，可用 Rewrap 迁移到新密钥
+// KeyRing 可并发使用
+type KeyRing struct {
+	mu        sync.RWMutex
+	keys      map[string][]byte
+	primary   string
+	algorithm Algorithm
+}
+
+// ErrNoPrimaryKey 未设置主密钥
+var ErrNoPrimaryKey = errors.New("gaes: 未设置主密钥")
+
+// NewKeyRing 创建密钥环，加密使用 AES-GCM
+func NewKeyRing() *KeyRing {
+	return &KeyRing{
+		keys:      make(map[string][]byte),
+		algorithm: AlgAESGCM,
+	}
+}
+
+// Add 添加密钥，密钥 ID 已存在时返回错误
+func (r *KeyRing) Add(keyID string, key []byte) error {
+	if len(keyID) == 0 || len(keyID) > 255 {
+		return errors.New("gaes: 密钥ID长度必须为 1-255 字节")
+	}
+	switch len(key) {
+	case 16, 24, 32:
+	default:
+		return aes.KeySizeError(len(key))
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if _, ok := r.keys[keyID]; ok {
+		return fmt.Errorf("gaes: 密钥ID %q 已存在", keyID)
+	}
+	r.keys[keyID] = append([]byte(nil), key...)
+	return nil
+}
+
+// SetPrimary 设置加密使用的主密钥
+func (r *KeyRing) SetPrimary(keyID string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if _, ok := r.keys[keyID]; !ok {
+		return fmt.Errorf("%w: %q", ErrKeyNotFound, keyID)
+	}
+	r.primary = keyID
+	return nil
+}
+
+// Rotate 添加新密钥并设为主密钥
+func (r *KeyRing) Rotate(keyID string, key []byte) error {
+	if err := r.Add(keyID, key); err != nil {
+		return err
+	}
+	return r.SetPrimary(keyID)
+}
+
+// SetAlgorithm 设置加密使用的信封算法，默认 AlgAESGCM，已加密的数据按信封中记录的算法解密
+func (r *KeyRing) SetAlgorithm(alg Algorithm) error {
+	if _, ok := envelopeCiphers[alg]; !ok {
+		return fmt.Errorf("%w: %s", ErrUnsupportedAlgorithm, alg)
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.algorithm = alg
+	return nil
+}
+
+// Remove 移除不再使用的密钥，不能移除主密钥
+func (r *KeyRing) Remove(keyID string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if keyID == r.primary {
+		return fmt.Errorf("gaes: 不能移除主密钥 %q", keyID)
+	}
+	delete(r.keys, keyID)
+	return nil
+}
+
+// Primary 返回主密钥 ID
+func (r *KeyRing) Primary() string {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	return r.primary
+}
+
+// KeyIDs 返回所有密钥 ID
+func (r *KeyRing) KeyIDs() []string {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	ids := make([]string, 0, len(r.keys))
+	for id := range r.keys {
+		ids = append(ids, id)
+	}
+	return ids
+}
+
+// ResolveKey 实现 KeyResolver，返回密钥的副本，调用方修改或清零不影响密钥环
+func (r *KeyRing) ResolveKey(keyID string) ([]byte, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	key, ok := r.keys[keyID]
+	if !ok {
+		return nil, fmt.Errorf("%w: %q", ErrKeyNotFound, keyID)
+	}
+	return bytes.Clone(key), nil
+}
+
+// primaryKey 返回加密算法、主密钥 ID 和密钥
+func (r *KeyRing) primaryKey() (Algorithm, string, []byte, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	if r.primary == "" {
+		return 0, "", nil, ErrNoPrimaryKey
+	}
+	return r.algorithm, r.primary, r.keys[r.primary], nil
+}
+
+// Seal 使用主密钥加密，返回信封
+func (r *KeyRing) Seal(plaintext, additionalData []byte) (*Envelope, error) {
+	alg, keyID, key, err := r.primaryKey()
+	if err != nil {
+		return nil, err
+	}
+	return SealEnvelope(alg, keyID, key, plaintext, additionalData)
+}
+
+// Encrypt 使用主密钥加密，返回二进制信封
+func (r *KeyRing) Encrypt(plaintext, additionalData []byte) ([]byte, error) {
+	env, err := r.Seal(plaintext, additionalData)
+	if err != nil {
+		return nil, err
+	}
+	return env.MarshalBinary()
+}
+
+// Decrypt 解密二进制或文本信封，根据密钥 ID 自动选择密钥
+func (r *KeyRing) Decrypt(data, additionalData []byte) ([]byte, error) {
+	return OpenEnvelope(data, r, additionalData)
+}
+
+// Rewrap 使用当前主密钥重新加密旧密文，输出编码（二进制/文本）与输入一致
+// 已经由主密钥加密的密文也会重新生成
+func (r *KeyRing) Rewrap(data, additionalData []byte) ([]byte, error) {
+	plaintext, err := r.Decrypt(data, additionalData)
+	if err != nil {
+		return nil, err
+	}
+	env, err := r.Seal(plaintext, additionalData)
+	if err != nil {
+		return nil, err
+	}
+	if len(data) > 0 && data[0] == formatEnvelope {
+		return env.MarshalBinary()
+	}
+	return env.MarshalText()
+}
@@ -0,0 +1,87 @@
+package gaes
+
+import (
+	"bytes"
+	"errors"
+	"testing"
+)
+
+func TestKeyRing(t *testing.T) {
+	ring := NewKeyRing()
+	if _, err := ring.Encrypt([]byte("123"), nil); !errors.Is(err, ErrNoPrimaryKey) {
+		t.Errorf("未设置主密钥应返回 ErrNoPrimaryKey, got %v", err)
+	}
+	if err := ring.Rotate("2024q1", []byte("1111111111111111")); err != nil {
+		t.Error(err)
+		return
+	}
+	data := []byte("123")
+	old, err := ring.Encrypt(data, nil)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	if err := ring.Rotate("2024q2", []byte("22222222222222222222222222222222")); err != nil {
+		t.Error(err)
+		return
+	}
+	// 旧密钥加密的数据仍可解密
+	dd, err := ring.Decrypt(old, nil)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	if !bytes.Equal(dd, data) {
+		t.Errorf("解密结果不一致: %q", dd)
+	}
+	// 迁移到新主密钥后可移除旧密钥
+	rewrapped, err := ring.Rewrap(old, nil)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	env, err := ParseEnvelope(rewrapped)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	if env.KeyID != "2024q2" {
+		t.Errorf("Rewrap 后密钥ID应为 2024q2, got %q", env.KeyID)
+	}
+	if err := ring.Remove("2024q2"); err == nil {
+		t.Error("不应允许移除主密钥")
+	}
+	if err := ring.Remove("2024q1"); err != nil {
+		t.Error(err)
+	}
+	if _, err := ring.Decrypt(old, nil); !errors.Is(err, ErrKeyNotFound) {
+		t.Errorf("旧密钥移除后应返回 ErrKeyNotFound, got %v", err)
+	}
+	if dd, err := ring.Decrypt(rewrapped, nil); err != nil || !bytes.Equal(dd, data) {
+		t.Errorf("Rewrap 结果解密失败: %q %v", dd, err)
+	}
+}
+
+// 调用方修改 ResolveKey 返回的密钥不能影响密钥环
+func TestKeyRingResolveKeyCopy(t *testing.T) {
+	ring := NewKeyRing()
+	key := []byte("11111111111111111111111111111111")
+	if err := ring.Rotate("k1", key); err != nil {
+		t.Fatal(err)
+	}
+	ed, err := ring.Encrypt([]byte("123"), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	resolved, err := ring.ResolveKey("k1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	clear(resolved)
+	if again, _ := ring.ResolveKey("k1"); !bytes.Equal(again, key) {
+		t.Fatalf("密钥环中的密钥被修改: %x", again)
+	}
+	if dd, err := ring.Decrypt(ed, nil); err != nil || string(dd) != "123" {
+		t.Fatalf("解密失败 %q %v", dd, err)
+	}
+}
@@ -0,0 +1,228 @@
+package gaes
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+
+	"github.com/nonex-code/toolset/gsm2"
+)
+
+// 信封加密（KMS 模式）：每个对象使用随机数据密钥加密，数据密钥再由长期主密钥包装后与密文放在一起
+//
+// 格式：
+//  版本号(1) || 包装密钥长度(2) || 包装后的数据密钥 || AES-256-GCM(nonce || 密文 || 标签)
+// 包装后的数据密钥之前的头部作为附加认证数据
+
+// dataKeySize 数据密钥长度，使用 AES-256
+const dataKeySize = 32
+
+// KeyWrapper 使用主密钥包装/解包数据密钥
+type KeyWrapper interface {
+	// WrapKey 包装数据密钥
+	WrapKey(key []byte) ([]byte, error)
+	// UnwrapKey 解包数据密钥
+	UnwrapKey(wrapped []byte) ([]byte, error)
+}
+
+// ErrUnwrapKey 解包失败，主密钥错误或包装数据被篡改
+var ErrUnwrapKey = errors.New("gaes: 数据密钥解包失败")
+
+// EnvelopeEncrypt 生成随机数据密钥加密 plaintext，并把包装后的数据密钥保存在密文中
+func EnvelopeEncrypt(plaintext []byte, wrapper KeyWrapper, additionalData []byte) ([]byte, error) {
+	dataKey := make([]byte, dataKeySize)
+	if _, err := io.ReadFull(rand.Reader, dataKey); err != nil {
+		return nil, err
+	}
+	wrapped, err := wrapper.WrapKey(dataKey)
+	if err != nil {
+		return nil, err
+	}
+	if len(wrapped) > 0xffff {
+		return nil, errors.New("gaes: 包装后的数据密钥过长")
+	}
+	header := make([]byte, 3, 3+len(wrapped))
+	header[0] = formatWrapped
+	binary.BigEndian.PutUint16(header[1:], uint16(len(wrapped)))
+	header = append(header, wrapped...)
+	sealed, err := SealGCM(plaintext, dataKey, append(header[:len(header):len(header)], additionalData...))
+	if err != nil {
+		return nil, err
+	}
+	return append(header, sealed...), nil
+}
+
+// EnvelopeDecrypt 使用主密钥解包数据密钥后解密 EnvelopeEncrypt 的输出
+func EnvelopeDecrypt(data []byte, wrapper KeyWrapper, additionalData []byte) ([]byte, error) {
+	if len(data) < 3 {
+		return nil, ErrCiphertextTooShort
+	}
+	if data[0] != formatWrapped {
+		return nil, ErrUnknownFormat
+	}
+	n := 3 + int(binary.BigEndian.Uint16(data[1:3]))
+	if len(data) < n {
+		return nil, ErrCiphertextTooShort
+	}
+	dataKey, err := wrapper.UnwrapKey(data[3:n])
+	if err != nil {
+		return nil, err
+	}
+	return OpenGCM(data[n:], dataKey, append(data[:n:n], additionalData...))
+}
+
+// AESKeyWrapper AES 密钥包装，RFC 3394（AES-KW）和 RFC 5649（AES-KWP）
+type AESKeyWrapper struct {
+	block cipher.Block
+}
+
+// NewAESKeyWrapper 使用 16、24、32 字节主密钥创建 AESKeyWrapper
+func NewAESKeyWrapper(kek []byte) (*AESKeyWrapper, error) {
+	block, err := aes.NewCipher(kek)
+	if err != nil {
+		return nil, err
+	}
+	return &AESKeyWrapper{block: block}, nil
+}
+
+// WrapKey 长度为 8 的倍数且不小于 16 字节的密钥使用 RFC 3394，其他长度使用 RFC 5649
+func (w *AESKeyWrapper) WrapKey(key []byte) ([]byte, error) {
+	if len(key) >= 16 && len(key)%8 == 0 {
+		return aesKeyWrap(w.block, kwDefaultIV, key), nil
+	}
+	return w.WrapKeyWithPadding(key)
+}
+
+// WrapKeyWithPadding 使用 RFC 5649 包装任意长度的密钥
+func (w *AESKeyWrapper) WrapKeyWithPadding(key []byte) ([]byte, error) {
+	if len(key) == 0 || uint64(len(key)) > 0xffffffff {
+		return nil, errors.New("gaes: 被包装的密钥长度无效")
+	}
+	iv := make([]byte, 8)
+	copy(iv, kwpIVPrefix)
+	binary.BigEndian.PutUint32(iv[4:], uint32(len(key)))
+	padded := make([]byte, (len(key)+7)/8*8)
+	copy(padded, key)
+	if len(padded) == 8 {
+		out := make([]byte, 16)
+		copy(out, iv)
+		copy(out[8:], padded)
+		w.block.Encrypt(out, out)
+		return out, nil
+	}
+	return aesKeyWrap(w.block, iv, padded), nil
+}
+
+// UnwrapKey 解包 RFC 3394 或 RFC 5649 包装的密钥，根据恢复出的 IV 自动识别
+func (w *AESKeyWrapper) UnwrapKey(wrapped []byte) ([]byte, error) {
+	if len(wrapped) < 16 || len(wrapped)%8 != 0 {
+		return nil, ErrUnwrapKey
+	}
+	var iv, key []byte
+	if len(wrapped) == 16 {
+		out := make([]byte, 16)
+		w.block.Decrypt(out, wrapped)
+		iv, key = out[:8], out[8:]
+	} else {
+		iv, key = aesKeyUnwrap(w.block, wrapped)
+	}
+	if subtle.ConstantTimeCompare(iv, kwDefaultIV) == 1 && len(wrapped) > 16 {
+		return key, nil
+	}
+	//RFC 5649：校验 IV 前缀、长度和填充
+	if subtle.ConstantTimeCompare(iv[:4], kwpIVPrefix) != 1 {
+		return nil, ErrUnwrapKey
+	}
+	mli := int(binary.BigEndian.Uint32(iv[4:]))
+	if mli > len(key) || mli <= len(key)-8 {
+		return nil, ErrUnwrapKey
+	}
+	if subtle.ConstantTimeCompare(key[mli:], make([]byte, len(key)-mli)) != 1 {
+		return nil, ErrUnwrapKey
+	}
+	return key[:mli], nil
+}
+
+var (
+	// kwDefaultIV RFC 3394 默认 IV
+	kwDefaultIV = []byte{0xa6, 0xa6, 0xa6, 0xa6, 0xa6, 0xa6, 0xa6, 0xa6}
+	// kwpIVPrefix RFC 5649 IV 前缀，后 4 字节为密钥长度
+	kwpIVPrefix = []byte{0xa6, 0x59, 0x59, 0xa6}
+)
+
+// aesKeyWrap RFC 3394 2.2.1 包装，key 长度为 8 的倍数且至少 16 字节
+func aesKeyWrap(block cipher.Block, iv, key []byte) []byte {
+	n := len(key) / 8
+	out := make([]byte, 8+len(key))
+	copy(out[8:], key)
+	a := append([]byte(nil), iv...)
+	b := make([]byte, 16)
+	for j := 0; j < 6; j++ {
+		for i := 1; i <= n; i++ {
+			copy(b, a)
+			copy(b[8:], out[8*i:8*i+8])
+			block.Encrypt(b, b)
+			t := uint64(n*j + i)
+			binary.BigEndian.PutUint64(a, binary.BigEndian.Uint64(b[:8])^t)
+			copy(out[8*i:], b[8:])
+		}
+	}
+	copy(out, a)
+	return out
+}
+
+// aesKeyUnwrap RFC 3394 2.2.2 解包，返回恢复出的 IV 和密钥
+func aesKeyUnwrap(block cipher.Block, wrapped []byte) (iv, key []byte) {
+	n := len(wrapped)/8 - 1
+	key = make([]byte, len(wrapped)-8)
+	copy(key, wrapped[8:])
+	a := append([]byte(nil), wrapped[:8]...)
+	b := make([]byte, 16)
+	for j := 5; j >= 0; j-- {
+		for i := n; i >= 1; i-- {
+			t := uint64(n*j + i)
+			binary.BigEndian.PutUint64(b, binary.BigEndian.Uint64(a)^t)
+			copy(b[8:], key[8*(i-1):8*i])
+			block.Decrypt(b, b)
+			copy(a, b[:8])
+			copy(key[8*(i-1):], b[8:])
+		}
+	}
+	return a, key
+}
+
+// SM2KeyWrapper 使用 SM2 公钥包装、私钥解包数据密钥，只做包装时可以不设置私钥，
+// 未设置公钥时使用私钥对应的公钥。密钥可用 gsm2.ParsePublicKeyPEM、gsm2.ParsePrivateKeyPEM 解析
+type SM2KeyWrapper struct {
+	PublicKey  *gsm2.PublicKey
+	PrivateKey *gsm2.PrivateKey
+}
+
+// WrapKey 使用 SM2 公钥加密数据密钥
+func (w *SM2KeyWrapper) WrapKey(key []byte) ([]byte, error) {
+	pub := w.PublicKey
+	if pub == nil {
+		if w.PrivateKey == nil {
+			return nil, errors.New("gaes: SM2KeyWrapper 未设置公钥")
+		}
+		pub = w.PrivateKey.Public()
+	}
+	return pub.Encrypt(key)
+}
+
+// UnwrapKey 使用 SM2 私钥解密数据密钥
+func (w *SM2KeyWrapper) UnwrapKey(wrapped []byte) ([]byte, error) {
+	if w.PrivateKey == nil {
+		return nil, errors.New("gaes: SM2KeyWrapper 未设置私钥")
+	}
+	key, err := w.PrivateKey.Decrypt(wrapped)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrUnwrapKey, err)
+	}
+	return key, nil
+}
@@ -0,0 +1,112 @@
+package gaes
+
+import (
+	"bytes"
+	"encoding/hex"
+	"errors"
+	"testing"
+
+	"github.com/nonex-code/toolset/gsm2"
+)
+
+// RFC 3394 4.1、4.6 和 RFC 5649 6 测试向量
+func TestAESKeyWrapVectors(t *testing.T) {
+	cases := []struct {
+		name string
+		kek  string
+		key  string
+		want string
+	}{
+		{"RFC 3394 4.1", "000102030405060708090a0b0c0d0e0f", "00112233445566778899aabbccddeeff", "1fa68b0a8112b447aef34bd8fb5a7b829d3e862371d2cfe5"},
+		{"RFC 3394 4.6", "000102030405060708090a0b0c0d0e0f101112131415161718191a1b1c1d1e1f", "00112233445566778899aabbccddeeff000102030405060708090a0b0c0d0e0f", "28c9f404c4b810f4cbccb35cfb87f8263f5786e2d80ed326cbc7f0e71a99f43bfb988b9b7a02dd21"},
+		{"RFC 5649 20 字节", "5840df6e29b02af1ab493b705bf16ea1ae8338f4dcc176a8", "c37b7e6492584340bed12207808941155068f738", "138bdeaa9b8fa7fc61f97742e72248ee5ae6ae5360d1ae6a5f54f373fa543b6a"},
+		{"RFC 5649 7 字节", "5840df6e29b02af1ab493b705bf16ea1ae8338f4dcc176a8", "466f7250617369", "afbeb0f07dfbf5419200f2ccb50bb24f"},
+	}
+	for _, c := range cases {
+		w, err := NewAESKeyWrapper(mustHex(c.kek))
+		if err != nil {
+			t.Fatal(err)
+		}
+		wrapped, err := w.WrapKey(mustHex(c.key))
+		if err != nil {
+			t.Errorf("%s: %v", c.name, err)
+			continue
+		}
+		if hex.EncodeToString(wrapped) != c.want {
+			t.Errorf("%s: got %x", c.name, wrapped)
+		}
+		key, err := w.UnwrapKey(wrapped)
+		if err != nil || hex.EncodeToString(key) != c.key {
+			t.Errorf("%s: 解包失败 %x %v", c.name, key, err)
+		}
+		wrapped[len(wrapped)-1] ^= 1
+		if _, err := w.UnwrapKey(wrapped); !errors.Is(err, ErrUnwrapKey) {
+			t.Errorf("%s: 篡改后应返回 ErrUnwrapKey, got %v", c.name, err)
+		}
+	}
+}
+
+func TestEnvelopeEncrypt(t *testing.T) {
+	aesWrapper, err := NewAESKeyWrapper([]byte("11111111111111111111111111111111"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	private, public, err := gsm2.GerenateSM2Key(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	priv, err := gsm2.ParsePrivateKeyPEM(private, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	pub, err := gsm2.ParsePublicKeyPEM(public)
+	if err != nil {
+		t.Fatal(err)
+	}
+	sm2Wrapper := &SM2KeyWrapper{PublicKey: pub, PrivateKey: priv}
+	data := bytes.Repeat([]byte("large object "), 100)
+	ad := []byte("bucket/object")
+	for _, w := range []KeyWrapper{aesWrapper, sm2Wrapper} {
+		ed, err := EnvelopeEncrypt(data, w, ad)
+		if err != nil {
+			t.Errorf("%T: %v", w, err)
+			continue
+		}
+		dd, err := EnvelopeDecrypt(ed, w, ad)
+		if err != nil || !bytes.Equal(dd, data) {
+			t.Errorf("%T: 解密失败 %v", w, err)
+		}
+		if _, err := EnvelopeDecrypt(ed, w, []byte("other")); !errors.Is(err, ErrAuthentication) {
+			t.Errorf("%T: 附加数据不一致应认证失败, got %v", w, err)
+		}
+	}
+	// 只有公钥时只能包装
+	ed, err := EnvelopeEncrypt(data, &SM2KeyWrapper{PublicKey: pub}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := EnvelopeDecrypt(ed, &SM2KeyWrapper{PublicKey: pub}, nil); err == nil {
+		t.Error("未设置私钥时解密应失败")
+	}
+	// 只有私钥时使用对应的公钥包装
+	if _, err := EnvelopeDecrypt(ed, &SM2KeyWrapper{PrivateKey: priv}, nil); err != nil {
+		t.Error(err)
+	}
+	// 解包失败时保留底层错误
+	other, _, err := gsm2.GerenateSM2Key(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	otherPriv, err := gsm2.ParsePrivateKeyPEM(other, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	wrapped, err := sm2Wrapper.WrapKey([]byte("1111111111111111"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = (&SM2KeyWrapper{PrivateKey: otherPriv}).UnwrapKey(wrapped)
+	if !errors.Is(err, ErrUnwrapKey) || err == ErrUnwrapKey {
+		t.Errorf("私钥不匹配应返回包含底层原因的 ErrUnwrapKey, got %v", err)
+	}
+}
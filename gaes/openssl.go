@@ -0,0 +1,168 @@
+package gaes
+
+import (
+	"bytes"
+	"crypto/rand"
+	"crypto/sha256"
+	"errors"
+	"fmt"
+	"hash"
+	"io"
+
+	"golang.org/x/crypto/pbkdf2"
+)
+
+// 兼容 `openssl enc` 的口令加密格式，用于与 Java、Python 及命令行互通：
+//
+//	Salted__ || salt(8) || 密文
+//
+// 密钥和 IV 由口令和 salt 派生，默认与下面的命令一致（AES-256-CBC、PBKDF2-HMAC-SHA256、10000 次迭代）：
+//
+//	openssl enc -aes-256-cbc -pbkdf2 -iter 10000 -md sha256 -pass pass:123 -in a.txt -out a.enc
+//
+// 旧版 openssl 不带 -pbkdf2 时使用 EVP_BytesToKey，需要 WithOpenSSLBytesToKey，
+// openssl 1.1.0 之前的默认摘要为 MD5，需要同时 WithOpenSSLDigest(md5.New)。
+// 输出为二进制，对应 openssl 的 -a 参数时自行做 base64 编码。
+// 该格式没有认证，口令错误或密文被篡改时通常返回 ErrInvalidPadding，仅在需要互通时使用
+
+const (
+	openSSLMagic       = "Salted__"
+	openSSLSaltSize    = 8
+	openSSLDefaultIter = 10000
+	openSSLIVSize      = 16
+)
+
+// ErrOpenSSLFormat 不是 OpenSSL Salted__ 格式
+var ErrOpenSSLFormat = errors.New("gaes: 不是 OpenSSL Salted__ 格式")
+
+// OpenSSLOption OpenSSL 格式参数
+type OpenSSLOption func(*openSSLConfig)
+
+type openSSLConfig struct {
+	keySize int
+	mode    Mode
+	digest  func() hash.Hash
+	// iterations PBKDF2 迭代次数，0 表示使用 EVP_BytesToKey
+	iterations int
+}
+
+// WithOpenSSLKeySize 设置 AES 密钥长度，16、24、32 对应 -aes-128/-aes-192/-aes-256，默认 32
+func WithOpenSSLKeySize(size int) OpenSSLOption {
+	return func(c *openSSLConfig) {
+		c.keySize = size
+	}
+}
+
+// WithOpenSSLMode 设置工作模式，支持 ModeCBC（默认）、ModeCTR、ModeCFB、ModeOFB
+func WithOpenSSLMode(mode Mode) OpenSSLOption {
+	return func(c *openSSLConfig) {
+		c.mode = mode
+	}
+}
+
+// WithOpenSSLDigest 设置摘要算法，对应 -md，默认 SHA-256
+func WithOpenSSLDigest(h func() hash.Hash) OpenSSLOption {
+	return func(c *openSSLConfig) {
+		c.digest = h
+	}
+}
+
+// WithOpenSSLIterations 设置 PBKDF2 迭代次数，对应 -iter，默认 10000
+func WithOpenSSLIterations(n int) OpenSSLOption {
+	return func(c *openSSLConfig) {
+		c.iterations = n
+	}
+}
+
+// WithOpenSSLBytesToKey 使用 EVP_BytesToKey 派生密钥，对应不带 -pbkdf2 的旧格式
+func WithOpenSSLBytesToKey() OpenSSLOption {
+	return func(c *openSSLConfig) {
+		c.iterations = 0
+	}
+}
+
+func newOpenSSLConfig(opts []OpenSSLOption) (*openSSLConfig, error) {
+	c := &openSSLConfig{
+		keySize:    32,
+		mode:       ModeCBC,
+		digest:     sha256.New,
+		iterations: openSSLDefaultIter,
+	}
+	for _, opt := range opts {
+		opt(c)
+	}
+	switch c.mode {
+	case ModeCBC, ModeCTR, ModeCFB, ModeOFB:
+	default:
+		return nil, fmt.Errorf("gaes: OpenSSL 格式不支持 %s 模式", c.mode)
+	}
+	if c.iterations < 0 || c.iterations > maxPBKDF2Iterations {
+		return nil, fmt.Errorf("%w: PBKDF2 Iterations=%d", ErrInvalidKDFParams, c.iterations)
+	}
+	return c, nil
+}
+
+// cipher 从口令和 salt 派生密钥和 IV，创建固定 IV 的 Cipher
+func (c *openSSLConfig) cipher(password, salt []byte) (*Cipher, error) {
+	var km []byte
+	if c.iterations == 0 {
+		km = evpBytesToKey(c.digest, password, salt, c.keySize+openSSLIVSize)
+	} else {
+		km = pbkdf2.Key(password, salt, c.iterations, c.keySize+openSSLIVSize, c.digest)
+	}
+	return New(km[:c.keySize], WithMode(c.mode), WithIV(km[c.keySize:]))
+}
+
+// evpBytesToKey OpenSSL EVP_BytesToKey，迭代次数为 1：D_i = H(D_{i-1} || password || salt)
+func evpBytesToKey(h func() hash.Hash, password, salt []byte, length int) []byte {
+	var out, d []byte
+	for len(out) < length {
+		m := h()
+		m.Write(d)
+		m.Write(password)
+		m.Write(salt)
+		d = m.Sum(nil)
+		out = append(out, d...)
+	}
+	return out[:length]
+}
+
+// EncryptOpenSSL 使用口令加密为 OpenSSL Salted__ 格式，每次调用使用随机 salt
+func EncryptOpenSSL(data, password []byte, opts ...OpenSSLOption) ([]byte, error) {
+	salt := make([]byte, openSSLSaltSize)
+	if _, err := io.ReadFull(rand.Reader, salt); err != nil {
+		return nil, err
+	}
+	return encryptOpenSSL(data, password, salt, opts)
+}
+
+func encryptOpenSSL(data, password, salt []byte, opts []OpenSSLOption) ([]byte, error) {
+	cfg, err := newOpenSSLConfig(opts)
+	if err != nil {
+		return nil, err
+	}
+	c, err := cfg.cipher(password, salt)
+	if err != nil {
+		return nil, err
+	}
+	out := make([]byte, 0, len(openSSLMagic)+openSSLSaltSize+len(data)+c.Overhead())
+	out = append(append(out, openSSLMagic...), salt...)
+	return c.Seal(out, data, nil)
+}
+
+// DecryptOpenSSL 解密 OpenSSL Salted__ 格式，选项必须与加密时的 openssl 参数一致
+func DecryptOpenSSL(data, password []byte, opts ...OpenSSLOption) ([]byte, error) {
+	cfg, err := newOpenSSLConfig(opts)
+	if err != nil {
+		return nil, err
+	}
+	header := len(openSSLMagic) + openSSLSaltSize
+	if len(data) < header || !bytes.Equal(data[:len(openSSLMagic)], []byte(openSSLMagic)) {
+		return nil, ErrOpenSSLFormat
+	}
+	c, err := cfg.cipher(password, data[len(openSSLMagic):header])
+	if err != nil {
+		return nil, err
+	}
+	return c.Decrypt(data[header:])
+}
@@ -0,0 +1,184 @@
+package gaes
+
+import (
+	"crypto/subtle"
+)
+
+// Padding 分组模式的填充方式
+type Padding interface {
+	// Pad 填充到 blockSize 的整数倍，返回新的切片，不修改 data
+	Pad(data []byte, blockSize int) ([]byte, error)
+	// Unpad 去除填充
+	Unpad(data []byte, blockSize int) ([]byte, error)
+}
+
+var (
+	// PKCS7 PKCS#7 填充，缺几位补几个几
+	PKCS7 Padding = pkcs7{}
+	// ISO7816 ISO/IEC 7816-4 填充，先补一个 0x80 再补 0x00
+	ISO7816 Padding = iso7816{}
+	// ANSIX923 ANSI X.923 填充，补 0x00，最后一个字节为填充长度
+	ANSIX923 Padding = ansiX923{}
+	// ZeroPadding 补 0x00，明文已对齐时不填充；去除填充时删除末尾所有 0x00，
+	// 明文本身以 0x00 结尾时无法还原，仅用于与其他系统互通
+	ZeroPadding Padding = zeroPadding{}
+	// NoPadding 不填充，分组模式下明文长度必须是块大小的整数倍
+	NoPadding Padding = noPadding{}
+)
+
+// paddingFiller 内置填充方式实现，Cipher.Seal 直接把填充写入输出，避免 Pad 分配内存
+type paddingFiller interface {
+	// padSize 返回长度为 n 的明文需要的填充字节数
+	padSize(n, blockSize int) (int, error)
+	// fill 写入填充，out 的长度为 padSize 的结果
+	fill(out []byte, blockSize int)
+}
+
+// padWith 使用 paddingFiller 实现 Pad，返回新的切片
+func padWith(p paddingFiller, data []byte, blockSize int) ([]byte, error) {
+	padding, err := p.padSize(len(data), blockSize)
+	if err != nil {
+		return nil, err
+	}
+	out := make([]byte, len(data)+padding)
+	copy(out, data)
+	p.fill(out[len(data):], blockSize)
+	return out, nil
+}
+
+type pkcs7 struct{}
+
+func (p pkcs7) Pad(data []byte, blockSize int) ([]byte, error) {
+	return padWith(p, data, blockSize)
+}
+
+func (pkcs7) padSize(n, blockSize int) (int, error) {
+	return blockSize - n%blockSize, nil
+}
+
+func (pkcs7) fill(out []byte, blockSize int) {
+	for i := range out {
+		out[i] = byte(len(out))
+	}
+}
+
+func (pkcs7) Unpad(data []byte, blockSize int) ([]byte, error) {
+	return pkcs7UnPadding(data, blockSize)
+}
+
+type noPadding struct{}
+
+func (p noPadding) Pad(data []byte, blockSize int) ([]byte, error) {
+	return padWith(p, data, blockSize)
+}
+
+func (noPadding) padSize(n, blockSize int) (int, error) {
+	if n%blockSize != 0 {
+		return 0, ErrNotBlockAligned
+	}
+	return 0, nil
+}
+
+func (noPadding) fill(out []byte, blockSize int) {}
+
+func (noPadding) Unpad(data []byte, blockSize int) ([]byte, error) {
+	if len(data)%blockSize != 0 {
+		return nil, ErrNotBlockAligned
+	}
+	return data, nil
+}
+
+type iso7816 struct{}
+
+func (p iso7816) Pad(data []byte, blockSize int) ([]byte, error) {
+	return padWith(p, data, blockSize)
+}
+
+func (iso7816) padSize(n, blockSize int) (int, error) {
+	return blockSize - n%blockSize, nil
+}
+
+func (iso7816) fill(out []byte, blockSize int) {
+	clear(out)
+	out[0] = 0x80
+}
+
+// Unpad 以常量时间查找最后一个块中最后的非零字节，必须为 0x80
+func (iso7816) Unpad(data []byte, blockSize int) ([]byte, error) {
+	length := len(data)
+	if length == 0 || length%blockSize != 0 {
+		return nil, ErrNotBlockAligned
+	}
+	last := data[length-blockSize:]
+	found, index, value := 0, 0, 0
+	for i := 0; i < blockSize; i++ {
+		nonZero := subtle.ConstantTimeByteEq(last[i], 0) ^ 1
+		index = subtle.ConstantTimeSelect(nonZero, i, index)
+		value = subtle.ConstantTimeSelect(nonZero, int(last[i]), value)
+		found |= nonZero
+	}
+	if found&subtle.ConstantTimeEq(int32(value), 0x80) != 1 {
+		return nil, ErrInvalidPadding
+	}
+	return data[:length-blockSize+index], nil
+}
+
+type ansiX923 struct{}
+
+func (p ansiX923) Pad(data []byte, blockSize int) ([]byte, error) {
+	return padWith(p, data, blockSize)
+}
+
+func (ansiX923) padSize(n, blockSize int) (int, error) {
+	return blockSize - n%blockSize, nil
+}
+
+func (ansiX923) fill(out []byte, blockSize int) {
+	clear(out)
+	out[len(out)-1] = byte(len(out))
+}
+
+// Unpad 以常量时间校验填充，除最后一个字节外的填充字节必须为 0x00
+func (ansiX923) Unpad(data []byte, blockSize int) ([]byte, error) {
+	length := len(data)
+	if length == 0 || length%blockSize != 0 {
+		return nil, ErrNotBlockAligned
+	}
+	padding := int(data[length-1])
+	good := subtle.ConstantTimeLessOrEq(1, padding) & subtle.ConstantTimeLessOrEq(padding, blockSize)
+	last := data[length-blockSize:]
+	for i := 2; i <= blockSize; i++ {
+		inPadding := subtle.ConstantTimeLessOrEq(i, padding)
+		zero := subtle.ConstantTimeByteEq(last[blockSize-i], 0)
+		good &= zero | (inPadding ^ 1)
+	}
+	if good != 1 {
+		return nil, ErrInvalidPadding
+	}
+	return data[:length-padding], nil
+}
+
+type zeroPadding struct{}
+
+func (p zeroPadding) Pad(data []byte, blockSize int) ([]byte, error) {
+	return padWith(p, data, blockSize)
+}
+
+func (zeroPadding) padSize(n, blockSize int) (int, error) {
+	return (blockSize - n%blockSize) % blockSize, nil
+}
+
+func (zeroPadding) fill(out []byte, blockSize int) {
+	clear(out)
+}
+
+func (zeroPadding) Unpad(data []byte, blockSize int) ([]byte, error) {
+	if len(data)%blockSize != 0 {
+		return nil, ErrNotBlockAligned
+	}
+	n := len(data)
+	for n > 0 && data[n-1] == 0 {
+		n--
+	}
+	return data[:n], nil
+}
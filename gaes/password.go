@@ -0,0 +1,308 @@
+package gaes
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+
+	"golang.org/x/crypto/argon2"
+	"golang.org/x/crypto/pbkdf2"
+	"golang.org/x/crypto/scrypt"
+)
+
+// 基于口令的加密：使用 KDF 和随机 salt 从口令派生 AES-256 密钥，再用 AES-GCM 加密
+//
+// 格式：
+//  版本号(1) || KDF算法(1) || 参数长度(1) || KDF参数 || salt(16) || nonce(12) || 密文 || 标签(16)
+// KDF 参数保存在密文头部，之后提高参数不影响旧数据解密。头部作为附加认证数据
+
+const (
+	passwordSaltSize = 16
+	passwordKeySize  = 32
+)
+
+// KDF 参数的硬上限，加密和解密都不能超过，解密默认使用更低的 DefaultPasswordLimits
+// scrypt 内存约为 128·N·R 字节，Argon2id 的 Memory 单位为 KiB，两者都限制在 256 MiB 以内
+const (
+	maxPBKDF2Iterations = 10000000
+	maxScryptN          = 1 << 22
+	maxScryptR          = 32
+	maxScryptP          = 16
+	maxScryptMemory     = 256 << 20
+	maxArgon2Memory     = 256 * 1024
+	maxArgon2Time       = 10
+)
+
+// PasswordLimits 解密时接受的 KDF 参数上限，头部参数来自不可信输入，超出上限时在派生密钥前拒绝
+// 字段为 0 时使用 DefaultPasswordLimits 中的值，任何字段都不能超过上面的硬上限
+type PasswordLimits struct {
+	PBKDF2Iterations int
+	// ScryptMemory scrypt 内存上限（字节），按 128·N·R 计算
+	ScryptMemory int64
+	ScryptP      int
+	// Argon2Memory Argon2id 内存上限，单位为 KiB
+	Argon2Memory uint32
+	Argon2Time   uint32
+}
+
+// DefaultPasswordLimits 解密默认上限，可覆盖 DefaultKDF 和常见推荐参数，单次解密最多约 128 MiB 内存
+// 使用更高参数加密的数据需通过 WithPasswordLimits 放宽上限后解密
+var DefaultPasswordLimits = PasswordLimits{
+	PBKDF2Iterations: 2000000,
+	ScryptMemory:     128 << 20,
+	ScryptP:          4,
+	Argon2Memory:     128 * 1024,
+	Argon2Time:       4,
+}
+
+// PasswordOption DecryptWithPassword 的选项
+type PasswordOption func(*PasswordLimits)
+
+// WithPasswordLimits 设置解密时接受的 KDF 参数上限
+func WithPasswordLimits(limits PasswordLimits) PasswordOption {
+	return func(l *PasswordLimits) {
+		if limits.PBKDF2Iterations > 0 {
+			l.PBKDF2Iterations = limits.PBKDF2Iterations
+		}
+		if limits.ScryptMemory > 0 {
+			l.ScryptMemory = limits.ScryptMemory
+		}
+		if limits.ScryptP > 0 {
+			l.ScryptP = limits.ScryptP
+		}
+		if limits.Argon2Memory > 0 {
+			l.Argon2Memory = limits.Argon2Memory
+		}
+		if limits.Argon2Time > 0 {
+			l.Argon2Time = limits.Argon2Time
+		}
+	}
+}
+
+// check 检查 KDF 参数是否在上限之内
+func (l PasswordLimits) check(kdf KDF) error {
+	var ok bool
+	switch k := kdf.(type) {
+	case PBKDF2:
+		ok = k.Iterations <= l.PBKDF2Iterations
+	case Scrypt:
+		ok = 128*int64(k.N)*int64(k.R) <= l.ScryptMemory && k.P <= l.ScryptP
+	case Argon2id:
+		ok = k.Memory <= l.Argon2Memory && k.Time <= l.Argon2Time
+	}
+	if !ok {
+		return fmt.Errorf("%w: %#v 超出解密上限，可使用 WithPasswordLimits 放宽", ErrInvalidKDFParams, kdf)
+	}
+	return nil
+}
+
+// KDF 口令密钥派生算法，可选 PBKDF2、Scrypt、Argon2id
+type KDF interface {
+	// Derive 从口令和 salt 派生 keyLen 字节密钥
+	Derive(password, salt []byte, keyLen int) ([]byte, error)
+	kdfID() byte
+	marshal() []byte
+	validate() error
+}
+
+const (
+	kdfPBKDF2   byte = 1
+	kdfScrypt   byte = 2
+	kdfArgon2id byte = 3
+)
+
+// DefaultKDF 默认使用的 KDF，参数参考 RFC 9106 的推荐值
+var DefaultKDF KDF = Argon2id{Time: 3, Memory: 64 * 1024, Threads: 4}
+
+// ErrInvalidKDFParams KDF 参数无效
+var ErrInvalidKDFParams = errors.New("gaes: KDF 参数无效")
+
+// PBKDF2 PBKDF2-HMAC-SHA256
+type PBKDF2 struct {
+	Iterations int
+}
+
+// Derive 实现 KDF
+func (k PBKDF2) Derive(password, salt []byte, keyLen int) ([]byte, error) {
+	if err := k.validate(); err != nil {
+		return nil, err
+	}
+	return pbkdf2.Key(password, salt, k.Iterations, keyLen, sha256.New), nil
+}
+
+func (k PBKDF2) kdfID() byte { return kdfPBKDF2 }
+
+func (k PBKDF2) marshal() []byte {
+	return binary.BigEndian.AppendUint32(nil, uint32(k.Iterations))
+}
+
+func (k PBKDF2) validate() error {
+	if k.Iterations < 1 || k.Iterations > maxPBKDF2Iterations {
+		return fmt.Errorf("%w: PBKDF2 Iterations=%d", ErrInvalidKDFParams, k.Iterations)
+	}
+	return nil
+}
+
+// Scrypt scrypt，N 必须是 2 的幂
+type Scrypt struct {
+	N, R, P int
+}
+
+// Derive 实现 KDF
+func (k Scrypt) Derive(password, salt []byte, keyLen int) ([]byte, error) {
+	if err := k.validate(); err != nil {
+		return nil, err
+	}
+	return scrypt.Key(password, salt, k.N, k.R, k.P, keyLen)
+}
+
+func (k Scrypt) kdfID() byte { return kdfScrypt }
+
+func (k Scrypt) marshal() []byte {
+	b := binary.BigEndian.AppendUint32(nil, uint32(k.N))
+	b = binary.BigEndian.AppendUint32(b, uint32(k.R))
+	return binary.BigEndian.AppendUint32(b, uint32(k.P))
+}
+
+func (k Scrypt) validate() error {
+	if k.N <= 1 || k.N&(k.N-1) != 0 || k.N > maxScryptN ||
+		k.R < 1 || k.R > maxScryptR || k.P < 1 || k.P > maxScryptP ||
+		128*int64(k.N)*int64(k.R) > maxScryptMemory {
+		return fmt.Errorf("%w: Scrypt N=%d R=%d P=%d", ErrInvalidKDFParams, k.N, k.R, k.P)
+	}
+	return nil
+}
+
+// Argon2id Argon2id，Memory 单位为 KiB
+type Argon2id struct {
+	Time    uint32
+	Memory  uint32
+	Threads uint8
+}
+
+// Derive 实现 KDF
+func (k Argon2id) Derive(password, salt []byte, keyLen int) ([]byte, error) {
+	if err := k.validate(); err != nil {
+		return nil, err
+	}
+	return argon2.IDKey(password, salt, k.Time, k.Memory, k.Threads, uint32(keyLen)), nil
+}
+
+func (k Argon2id) kdfID() byte { return kdfArgon2id }
+
+func (k Argon2id) marshal() []byte {
+	b := binary.BigEndian.AppendUint32(nil, k.Time)
+	b = binary.BigEndian.AppendUint32(b, k.Memory)
+	return append(b, k.Threads)
+}
+
+func (k Argon2id) validate() error {
+	if k.Time < 1 || k.Time > maxArgon2Time || k.Threads < 1 || k.Memory < 8*uint32(k.Threads) || k.Memory > maxArgon2Memory {
+		return fmt.Errorf("%w: Argon2id Time=%d Memory=%d Threads=%d", ErrInvalidKDFParams, k.Time, k.Memory, k.Threads)
+	}
+	return nil
+}
+
+// unmarshalKDF 从头部解析 KDF 参数
+func unmarshalKDF(id byte, params []byte) (KDF, error) {
+	var kdf KDF
+	switch {
+	case id == kdfPBKDF2 && len(params) == 4:
+		kdf = PBKDF2{Iterations: int(binary.BigEndian.Uint32(params))}
+	case id == kdfScrypt && len(params) == 12:
+		kdf = Scrypt{
+			N: int(binary.BigEndian.Uint32(params)),
+			R: int(binary.BigEndian.Uint32(params[4:])),
+			P: int(binary.BigEndian.Uint32(params[8:])),
+		}
+	case id == kdfArgon2id && len(params) == 9:
+		kdf = Argon2id{
+			Time:    binary.BigEndian.Uint32(params),
+			Memory:  binary.BigEndian.Uint32(params[4:]),
+			Threads: params[8],
+		}
+	default:
+		return nil, ErrUnknownFormat
+	}
+	if err := kdf.validate(); err != nil {
+		return nil, err
+	}
+	return kdf, nil
+}
+
+// parsePasswordHeader 解析头部，返回 KDF、头部长度
+func parsePasswordHeader(data []byte) (KDF, int, error) {
+	if len(data) < 3 {
+		return nil, 0, ErrCiphertextTooShort
+	}
+	if data[0] != formatPassword {
+		return nil, 0, ErrUnknownFormat
+	}
+	n := 3 + int(data[2])
+	if len(data) < n+passwordSaltSize {
+		return nil, 0, ErrCiphertextTooShort
+	}
+	kdf, err := unmarshalKDF(data[1], data[3:n])
+	if err != nil {
+		return nil, 0, err
+	}
+	return kdf, n + passwordSaltSize, nil
+}
+
+// EncryptWithPassword 使用口令加密，kdf 为 nil 时使用 DefaultKDF
+func EncryptWithPassword(data, password []byte, kdf KDF) ([]byte, error) {
+	if kdf == nil {
+		kdf = DefaultKDF
+	}
+	if err := kdf.validate(); err != nil {
+		return nil, err
+	}
+	params := kdf.marshal()
+	header := make([]byte, 0, 3+len(params)+passwordSaltSize)
+	header = append(header, formatPassword, kdf.kdfID(), byte(len(params)))
+	header = append(header, params...)
+	salt := header[len(header) : len(header)+passwordSaltSize]
+	if _, err := io.ReadFull(rand.Reader, salt); err != nil {
+		return nil, err
+	}
+	header = header[:len(header)+passwordSaltSize]
+	key, err := kdf.Derive(password, salt, passwordKeySize)
+	if err != nil {
+		return nil, err
+	}
+	sealed, err := SealGCM(data, key, header)
+	if err != nil {
+		return nil, err
+	}
+	return append(header, sealed...), nil
+}
+
+// DecryptWithPassword 使用口令解密 EncryptWithPassword 的输出，口令错误返回 ErrAuthentication
+// 头部 KDF 参数超出 DefaultPasswordLimits 时返回 ErrInvalidKDFParams，可用 WithPasswordLimits 放宽
+func DecryptWithPassword(data, password []byte, opts ...PasswordOption) ([]byte, error) {
+	limits := DefaultPasswordLimits
+	for _, opt := range opts {
+		opt(&limits)
+	}
+	kdf, n, err := parsePasswordHeader(data)
+	if err != nil {
+		return nil, err
+	}
+	if err := limits.check(kdf); err != nil {
+		return nil, err
+	}
+	key, err := kdf.Derive(password, data[n-passwordSaltSize:n], passwordKeySize)
+	if err != nil {
+		return nil, err
+	}
+	return OpenGCM(data[n:], key, data[:n])
+}
+
+// PasswordKDF 返回密文头部记录的 KDF 参数，可用于判断是否需要以更高参数重新加密
+func PasswordKDF(data []byte) (KDF, error) {
+	kdf, _, err := parsePasswordHeader(data)
+	return kdf, err
+}
@@ -0,0 +1,149 @@
+package gaes
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestPassword(t *testing.T) {
+	data := []byte("123")
+	password := []byte("correct horse battery staple")
+	kdfs := []KDF{
+		nil,
+		PBKDF2{Iterations: 1000},
+		Scrypt{N: 1 << 10, R: 8, P: 1},
+		Argon2id{Time: 1, Memory: 1024, Threads: 1},
+	}
+	for _, kdf := range kdfs {
+		ed, err := EncryptWithPassword(data, password, kdf)
+		if err != nil {
+			t.Errorf("%#v: %v", kdf, err)
+			continue
+		}
+		dd, err := DecryptWithPassword(ed, password)
+		if err != nil {
+			t.Errorf("%#v: %v", kdf, err)
+			continue
+		}
+		if !bytes.Equal(dd, data) {
+			t.Errorf("%#v: 解密结果不一致: %q", kdf, dd)
+		}
+		got, err := PasswordKDF(ed)
+		if err != nil {
+			t.Error(err)
+			continue
+		}
+		want := kdf
+		if want == nil {
+			want = DefaultKDF
+		}
+		if got != want {
+			t.Errorf("头部 KDF 参数不一致: %#v != %#v", got, want)
+		}
+		if _, err := DecryptWithPassword(ed, []byte("wrong")); !errors.Is(err, ErrAuthentication) {
+			t.Errorf("%#v: 口令错误应认证失败, got %v", kdf, err)
+		}
+	}
+}
+
+func TestPasswordInvalidParams(t *testing.T) {
+	if _, err := EncryptWithPassword([]byte("123"), []byte("pwd"), Scrypt{N: 1000, R: 8, P: 1}); !errors.Is(err, ErrInvalidKDFParams) {
+		t.Errorf("N 不是 2 的幂应返回 ErrInvalidKDFParams, got %v", err)
+	}
+	ed, err := EncryptWithPassword([]byte("123"), []byte("pwd"), PBKDF2{Iterations: 1000})
+	if err != nil {
+		t.Fatal(err)
+	}
+	// 篡改头部参数为超大迭代次数，解密时应直接拒绝
+	ed[3], ed[4], ed[5], ed[6] = 0xff, 0xff, 0xff, 0xff
+	if _, err := DecryptWithPassword(ed, []byte("pwd")); !errors.Is(err, ErrInvalidKDFParams) {
+		t.Errorf("超大参数应返回 ErrInvalidKDFParams, got %v", err)
+	}
+}
+
+// 头部中的 KDF 参数来自不可信输入，超出上限时不能尝试派生
+func TestPasswordOversizedHeader(t *testing.T) {
+	cases := []struct {
+		name  string
+		kdf   KDF
+		patch func(params []byte)
+	}{
+		{"scrypt R", Scrypt{N: 1 << 10, R: 8, P: 1}, func(p []byte) { binary.BigEndian.PutUint32(p[4:], 1<<20) }},
+		{"scrypt P", Scrypt{N: 1 << 10, R: 8, P: 1}, func(p []byte) { binary.BigEndian.PutUint32(p[8:], 1<<10) }},
+		{"scrypt N·R", Scrypt{N: 1 << 10, R: 8, P: 1}, func(p []byte) {
+			binary.BigEndian.PutUint32(p, 1<<20)
+			binary.BigEndian.PutUint32(p[4:], 32)
+		}},
+		{"argon2 Memory", Argon2id{Time: 1, Memory: 1024, Threads: 1}, func(p []byte) { binary.BigEndian.PutUint32(p[4:], 4*1024*1024) }},
+		{"argon2 Time", Argon2id{Time: 1, Memory: 1024, Threads: 1}, func(p []byte) { binary.BigEndian.PutUint32(p, 100) }},
+	}
+	for _, c := range cases {
+		ed, err := EncryptWithPassword([]byte("123"), []byte("pwd"), c.kdf)
+		if err != nil {
+			t.Fatal(err)
+		}
+		c.patch(ed[3 : 3+int(ed[2])])
+		if _, err := DecryptWithPassword(ed, []byte("pwd")); !errors.Is(err, ErrInvalidKDFParams) {
+			t.Errorf("%s: 应返回 ErrInvalidKDFParams, got %v", c.name, err)
+		}
+		if _, err := PasswordKDF(ed); !errors.Is(err, ErrInvalidKDFParams) {
+			t.Errorf("%s: PasswordKDF 应返回 ErrInvalidKDFParams, got %v", c.name, err)
+		}
+	}
+	// 上限之内的参数仍可使用
+	if err := (Scrypt{N: 1 << 16, R: 32, P: 16}).validate(); err != nil {
+		t.Error(err)
+	}
+}
+
+// 硬上限之内但超出解密上限的头部应在派生密钥前拒绝
+func TestPasswordDecryptLimits(t *testing.T) {
+	cases := []struct {
+		name  string
+		kdf   KDF
+		patch func(params []byte)
+	}{
+		{"pbkdf2 Iterations", PBKDF2{Iterations: 1000}, func(p []byte) { binary.BigEndian.PutUint32(p, maxPBKDF2Iterations) }},
+		{"scrypt N·R", Scrypt{N: 1 << 10, R: 8, P: 1}, func(p []byte) {
+			binary.BigEndian.PutUint32(p, 1<<16)
+			binary.BigEndian.PutUint32(p[4:], 32)
+		}},
+		{"scrypt P", Scrypt{N: 1 << 10, R: 8, P: 1}, func(p []byte) { binary.BigEndian.PutUint32(p[8:], maxScryptP) }},
+		{"argon2 Memory", Argon2id{Time: 1, Memory: 1024, Threads: 1}, func(p []byte) { binary.BigEndian.PutUint32(p[4:], maxArgon2Memory) }},
+		{"argon2 Time", Argon2id{Time: 1, Memory: 1024, Threads: 1}, func(p []byte) { binary.BigEndian.PutUint32(p, maxArgon2Time) }},
+	}
+	for _, c := range cases {
+		ed, err := EncryptWithPassword([]byte("123"), []byte("pwd"), c.kdf)
+		if err != nil {
+			t.Fatal(err)
+		}
+		c.patch(ed[3 : 3+int(ed[2])])
+		kdf, err := PasswordKDF(ed)
+		if err != nil {
+			t.Fatalf("%s: 参数应在硬上限之内: %v", c.name, err)
+		}
+		start := time.Now()
+		if _, err := DecryptWithPassword(ed, []byte("pwd")); !errors.Is(err, ErrInvalidKDFParams) {
+			t.Errorf("%s: 应返回 ErrInvalidKDFParams, got %v", c.name, err)
+		}
+		// 派生一次需要数秒，拒绝应立即返回
+		if d := time.Since(start); d > time.Second {
+			t.Errorf("%#v: 拒绝前执行了 KDF，耗时 %v", kdf, d)
+		}
+	}
+
+	// 可通过选项调整上限
+	ed, err := EncryptWithPassword([]byte("123"), []byte("pwd"), PBKDF2{Iterations: 1000})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := DecryptWithPassword(ed, []byte("pwd"), WithPasswordLimits(PasswordLimits{PBKDF2Iterations: 999})); !errors.Is(err, ErrInvalidKDFParams) {
+		t.Errorf("低于上限应返回 ErrInvalidKDFParams, got %v", err)
+	}
+	if _, err := DecryptWithPassword(ed, []byte("pwd"), WithPasswordLimits(PasswordLimits{PBKDF2Iterations: 1000})); err != nil {
+		t.Error(err)
+	}
+}
@@ -0,0 +1,106 @@
+package gaes
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/subtle"
+	"errors"
+)
+
+// AES-SIV 确定性认证加密（RFC 5297），用于加密后仍需按相等条件查询的字段，如手机号、证件号
+//
+// 相同密钥、相同附加数据下，相同明文总是得到相同密文，因此会泄露“两条密文的明文是否相等”，
+// 除此之外不泄露其他信息，密文被篡改时解密返回 ErrAuthentication。
+// 不同用途的字段应使用不同的附加数据（如表名+列名），避免跨字段比较。
+//
+// 密钥长度为 32、48、64 字节，分别对应 AES-SIV-256、AES-SIV-384、AES-SIV-512，
+// 前一半用于 S2V（CMAC），后一半用于 CTR 加密。
+// 输出格式为 合成IV(16) || 密文，比明文长 16 字节
+
+// sivMaxAdditionalData 附加数据最多 126 项，加上明文共 127 项
+const sivMaxAdditionalData = 126
+
+// ErrSIVKeySize AES-SIV 密钥长度错误
+var ErrSIVKeySize = errors.New("gaes: AES-SIV 密钥长度必须为 32、48 或 64 字节")
+
+// newSIV 拆分密钥，返回 S2V 和 CTR 使用的分组密码
+func newSIV(key []byte, additionalData [][]byte) (mac, ctr cipher.Block, err error) {
+	switch len(key) {
+	case 32, 48, 64:
+	default:
+		return nil, nil, ErrSIVKeySize
+	}
+	if len(additionalData) > sivMaxAdditionalData {
+		return nil, nil, errors.New("gaes: AES-SIV 附加数据最多 126 项")
+	}
+	if mac, err = aes.NewCipher(key[:len(key)/2]); err != nil {
+		return nil, nil, err
+	}
+	if ctr, err = aes.NewCipher(key[len(key)/2:]); err != nil {
+		return nil, nil, err
+	}
+	return mac, ctr, nil
+}
+
+// s2v RFC 5297 2.4 S2V，对附加数据和明文计算合成 IV
+func s2v(block cipher.Block, additionalData [][]byte, plaintext []byte) []byte {
+	blockSize := block.BlockSize()
+	d := cmac(block, make([]byte, blockSize))
+	for _, ad := range additionalData {
+		dbl(d, d)
+		subtle.XORBytes(d, d, cmac(block, ad))
+	}
+	var t []byte
+	if len(plaintext) >= blockSize {
+		t = append([]byte(nil), plaintext...)
+		subtle.XORBytes(t[len(t)-blockSize:], t[len(t)-blockSize:], d)
+	} else {
+		dbl(d, d)
+		t = make([]byte, blockSize)
+		copy(t, plaintext)
+		t[len(plaintext)] = 0x80
+		subtle.XORBytes(t, t, d)
+	}
+	return cmac(block, t)
+}
+
+// sivCTR 以合成 IV 清除第 31、63 位后作为计数器进行 CTR 加解密
+func sivCTR(block cipher.Block, v, dst, src []byte) {
+	q := append([]byte(nil), v...)
+	q[8] &= 0x7f
+	q[12] &= 0x7f
+	cipher.NewCTR(block, q).XORKeyStream(dst, src)
+}
+
+// SealSIV 使用 AES-SIV 确定性加密，返回 合成IV || 密文
+// 可传入多项附加数据，基于 nonce 的用法可以把 nonce 作为最后一项附加数据
+func SealSIV(plaintext, key []byte, additionalData ...[]byte) ([]byte, error) {
+	mac, ctr, err := newSIV(key, additionalData)
+	if err != nil {
+		return nil, err
+	}
+	v := s2v(mac, additionalData, plaintext)
+	out := make([]byte, len(v)+len(plaintext))
+	copy(out, v)
+	sivCTR(ctr, v, out[len(v):], plaintext)
+	return out, nil
+}
+
+// OpenSIV 校验并解密 SealSIV 的输出，附加数据必须与加密时一致
+func OpenSIV(ciphertext, key []byte, additionalData ...[]byte) ([]byte, error) {
+	mac, ctr, err := newSIV(key, additionalData)
+	if err != nil {
+		return nil, err
+	}
+	blockSize := mac.BlockSize()
+	if len(ciphertext) < blockSize {
+		return nil, ErrCiphertextTooShort
+	}
+	v := ciphertext[:blockSize]
+	plaintext := make([]byte, len(ciphertext)-blockSize)
+	sivCTR(ctr, v, plaintext, ciphertext[blockSize:])
+	if subtle.ConstantTimeCompare(s2v(mac, additionalData, plaintext), v) != 1 {
+		return nil, ErrAuthentication
+	}
+	return plaintext, nil
+}
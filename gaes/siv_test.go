@@ -0,0 +1,98 @@
+package gaes
+
+import (
+	"bytes"
+	"crypto/aes"
+	"encoding/hex"
+	"errors"
+	"testing"
+)
+
+// RFC 4493 4. Test Vectors
+func TestCMAC(t *testing.T) {
+	block, _ := aes.NewCipher(mustHex("2b7e151628aed2a6abf7158809cf4f3c"))
+	msg := mustHex("6bc1bee22e409f96e93d7e117393172aae2d8a571e03ac9c9eb76fac45af8e5130c81c46a35ce411e5fbc1191a0a52eff69f2445df4f9b17ad2b417be66c3710")
+	cases := []struct {
+		length int
+		want   string
+	}{
+		{0, "bb1d6929e95937287fa37d129b756746"},
+		{16, "070a16b46b4d4144f79bdd9dd04a287c"},
+		{40, "dfa66747de9ae63030ca32611497c827"},
+		{64, "51f0bebf7e3b9d92fc49741779363cfe"},
+	}
+	for _, c := range cases {
+		if got := hex.EncodeToString(cmac(block, msg[:c.length])); got != c.want {
+			t.Errorf("len %d: got %s", c.length, got)
+		}
+	}
+}
+
+// RFC 5297 Appendix A
+func TestSIVVectors(t *testing.T) {
+	cases := []struct {
+		name      string
+		key       string
+		ad        []string
+		plaintext string
+		want      string
+	}{
+		{
+			name:      "A.1 Deterministic Authenticated Encryption",
+			key:       "fffefdfcfbfaf9f8f7f6f5f4f3f2f1f0f0f1f2f3f4f5f6f7f8f9fafbfcfdfeff",
+			ad:        []string{"101112131415161718191a1b1c1d1e1f2021222324252627"},
+			plaintext: "112233445566778899aabbccddee",
+			want:      "85632d07c6e8f37f950acd320a2ecc9340c02b9690c4dc04daef7f6afe5c",
+		},
+		{
+			name: "A.2 Nonce-Based Authenticated Encryption",
+			key:  "7f7e7d7c7b7a79787776757473727170404142434445464748494a4b4c4d4e4f",
+			ad: []string{
+				"00112233445566778899aabbccddeeffdeaddadadeaddadaffeeddccbbaa99887766554433221100",
+				"102030405060708090a0",
+				"09f911029d74e35bd84156c5635688c0",
+			},
+			plaintext: "7468697320697320736f6d6520706c61696e7465787420746f20656e6372797074207573696e67205349562d414553",
+			want:      "7bdb6e3b432667eb06f4d14bff2fbd0fcb900f2fddbe404326601965c889bf17dba77ceb094fa663b7a3f748ba8af829ea64ad544a272e9c485b62a3fd5c0d",
+		},
+	}
+	for _, c := range cases {
+		var ad [][]byte
+		for _, a := range c.ad {
+			ad = append(ad, mustHex(a))
+		}
+		ed, err := SealSIV(mustHex(c.plaintext), mustHex(c.key), ad...)
+		if err != nil {
+			t.Errorf("%s: %v", c.name, err)
+			continue
+		}
+		if hex.EncodeToString(ed) != c.want {
+			t.Errorf("%s: got %x", c.name, ed)
+		}
+		dd, err := OpenSIV(ed, mustHex(c.key), ad...)
+		if err != nil || hex.EncodeToString(dd) != c.plaintext {
+			t.Errorf("%s: 解密失败 %x %v", c.name, dd, err)
+		}
+	}
+}
+
+func TestSIVDeterministic(t *testing.T) {
+	key := bytes.Repeat([]byte{1}, 32)
+	column := []byte("users.phone")
+	a, _ := SealSIV([]byte("13800138000"), key, column)
+	b, _ := SealSIV([]byte("13800138000"), key, column)
+	if !bytes.Equal(a, b) {
+		t.Error("相同明文应得到相同密文")
+	}
+	c, _ := SealSIV([]byte("13800138000"), key, []byte("users.id_card"))
+	if bytes.Equal(a, c) {
+		t.Error("不同附加数据应得到不同密文")
+	}
+	a[len(a)-1] ^= 1
+	if _, err := OpenSIV(a, key, column); !errors.Is(err, ErrAuthentication) {
+		t.Errorf("篡改密文应认证失败, got %v", err)
+	}
+	if _, err := SealSIV([]byte("1"), key[:16]); !errors.Is(err, ErrSIVKeySize) {
+		t.Errorf("密钥长度错误应返回 ErrSIVKeySize, got %v", err)
+	}
+}
@@ -0,0 +1,146 @@
+package gaes
+
+import (
+	"bytes"
+	"encoding/hex"
+	"errors"
+	"testing"
+)
+
+// GB/T 32907 附录 A.1 单块示例，CBC/CTR 向量由 OpenSSL 生成：
+//
+//	openssl enc -sm4-cbc -K 0123456789abcdeffedcba9876543210 -iv 000102030405060708090a0b0c0d0e0f -nopad
+func TestSM4Vectors(t *testing.T) {
+	key := mustHex("0123456789abcdeffedcba9876543210")
+	iv := mustHex("000102030405060708090a0b0c0d0e0f")
+	plaintext := mustHex("aaaaaaaaaaaaaaaabbbbbbbbbbbbbbbbccccccccccccccccdddddddddddddddd")
+	cases := []struct {
+		name      string
+		opts      []Option
+		plaintext []byte
+		want      string
+	}{
+		{"ECB", []Option{WithInsecureECB(), WithPadding(NoPadding)}, key, "681edf34d206965e86b3e94f536e4246"},
+		{"ECB", []Option{WithInsecureECB(), WithPadding(NoPadding)}, plaintext, "df61fda16e0268082191a3a4dae58486cb75d4181812c44ea1caa50f82a88ead"},
+		{"CBC", []Option{WithIV(iv), WithPadding(NoPadding)}, plaintext, "9554bcddf2d371452bffd93df8d461872360664050b1ae28e3e25ab2539ededb"},
+		{"CTR", []Option{WithMode(ModeCTR), WithIV(iv)}, plaintext, "ac3236cb970cc20791364c395a1342d1a3cbc1878c6f30cd074cce385cdd70c7"},
+	}
+	for _, c := range cases {
+		ci, err := New(key, append(c.opts, WithBlockCipher(SM4))...)
+		if err != nil {
+			t.Fatal(err)
+		}
+		ed, err := ci.Encrypt(c.plaintext)
+		if err != nil {
+			t.Errorf("%s: %v", c.name, err)
+			continue
+		}
+		if hex.EncodeToString(ed) != c.want {
+			t.Errorf("%s: got %x", c.name, ed)
+		}
+		dd, err := ci.Decrypt(ed)
+		if err != nil || !bytes.Equal(dd, c.plaintext) {
+			t.Errorf("%s: 解密失败 %x %v", c.name, dd, err)
+		}
+	}
+}
+
+// RFC 8998 A.1 SM4-GCM 测试向量
+func TestSM4GCMVector(t *testing.T) {
+	key := mustHex("0123456789abcdeffedcba9876543210")
+	nonce := mustHex("00001234567800000000abcd")
+	ad := mustHex("feedfacedeadbeeffeedfacedeadbeefabaddad2")
+	plaintext := mustHex("aaaaaaaaaaaaaaaabbbbbbbbbbbbbbbbccccccccccccccccddddddddddddddddeeeeeeeeeeeeeeeeffffffffffffffffeeeeeeeeeeeeeeeeaaaaaaaaaaaaaaaa")
+	want := "17f399f08c67d5ee19d0dc9969c4bb7d5fd46fd3756489069157b282bb200735d82710ca5c22f0ccfa7cbf93d496ac15a56834cbcf98c397b4024a2691233b8d" +
+		"83de3541e4c2b58177e065a9bf7b62ec"
+	c, err := New(key, WithBlockCipher(SM4), WithMode(ModeGCM), WithIV(nonce))
+	if err != nil {
+		t.Fatal(err)
+	}
+	ed, err := c.Seal(nil, plaintext, ad)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if hex.EncodeToString(ed) != want {
+		t.Errorf("got %x", ed)
+	}
+	dd, err := c.Open(nil, ed, ad)
+	if err != nil || !bytes.Equal(dd, plaintext) {
+		t.Errorf("解密失败 %x %v", dd, err)
+	}
+}
+
+// tjfoc/gmsm 的 SM4 实例不能并发使用，Cipher 需要保证并发安全，配合 -race 运行
+func TestSM4Concurrent(t *testing.T) {
+	for _, mode := range []Mode{ModeCBC, ModeGCM} {
+		c, err := New([]byte("1111111111111111"), WithBlockCipher(SM4), WithMode(mode))
+		if err != nil {
+			t.Fatal(err)
+		}
+		data := bytes.Repeat([]byte("123"), 100)
+		done := make(chan error)
+		for i := 0; i < 8; i++ {
+			go func() {
+				var err error
+				for j := 0; j < 100 && err == nil; j++ {
+					var ed, dd []byte
+					if ed, err = c.Encrypt(data); err == nil {
+						if dd, err = c.Decrypt(ed); err == nil && !bytes.Equal(dd, data) {
+							err = errors.New("解密结果不一致")
+						}
+					}
+				}
+				done <- err
+			}()
+		}
+		for i := 0; i < 8; i++ {
+			if err := <-done; err != nil {
+				t.Errorf("%s: %v", mode, err)
+			}
+		}
+	}
+}
+
+func TestSM4Envelope(t *testing.T) {
+	ring := NewKeyRing()
+	if err := ring.SetAlgorithm(AlgSM4GCM); err != nil {
+		t.Fatal(err)
+	}
+	if err := ring.Rotate("sm4", []byte("1111111111111111")); err != nil {
+		t.Fatal(err)
+	}
+	ed, err := ring.Encrypt([]byte("123"), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	env, _ := ParseEnvelope(ed)
+	if env.Algorithm != AlgSM4GCM {
+		t.Errorf("信封算法应为 sm4-gcm, got %s", env.Algorithm)
+	}
+	if dd, err := ring.Decrypt(ed, nil); err != nil || string(dd) != "123" {
+		t.Errorf("解密失败 %q %v", dd, err)
+	}
+	key := []byte("1111111111111111")
+	cbc, err := SealEnvelope(AlgSM4CBC, "k", key, []byte("123"), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if dd, err := OpenEnvelope([]byte(cbc.String()), Keys{"k": key}, nil); err != nil || string(dd) != "123" {
+		t.Errorf("sm4-cbc 解密失败 %q %v", dd, err)
+	}
+}
+
+func TestParseBlockCipher(t *testing.T) {
+	for _, b := range []BlockCipher{AES, SM4} {
+		got, err := ParseBlockCipher(b.String())
+		if err != nil || got != b {
+			t.Errorf("%s: got %s %v", b, got, err)
+		}
+	}
+	if _, err := ParseBlockCipher("des"); !errors.Is(err, ErrUnsupportedAlgorithm) {
+		t.Errorf("未知算法应返回 ErrUnsupportedAlgorithm, got %v", err)
+	}
+	if _, err := New(make([]byte, 32), WithBlockCipher(SM4)); err == nil {
+		t.Error("SM4 密钥长度错误应返回错误")
+	}
+}
@@ -0,0 +1,262 @@
+package gaes
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/binary"
+	"errors"
+	"io"
+)
+
+// 流式分段认证加密（STREAM 结构），用于大文件，内存占用与文件大小无关
+//
+// 格式：
+//  头部：版本号(1) || 分段大小(4) || salt(16) || nonce前缀(7)
+//  分段：AES-GCM(明文分段) || 标签(16)，每段明文固定为分段大小，最后一段可以更短（可以为空）
+// 每个流使用 HKDF(key, salt) 派生独立的子密钥，分段 nonce 为 nonce前缀 || 分段序号(4) || 最后一段标记(1)，
+// 头部作为每段的附加认证数据。分段被删除、重排或末尾被截断都会在解密时报错
+
+const (
+	// streamSegmentSize 默认明文分段大小
+	streamSegmentSize = 64 * 1024
+	// streamMaxSegmentSize 允许的最大分段大小，防止恶意头部导致大内存分配
+	streamMaxSegmentSize = 16 * 1024 * 1024
+	streamSaltSize       = 16
+	streamPrefixSize     = 7
+	streamHeaderSize     = 1 + 4 + streamSaltSize + streamPrefixSize
+	streamTagSize        = 16
+)
+
+// ErrTruncated 密文流被截断
+var ErrTruncated = errors.New("gaes: 密文流被截断")
+
+// newStreamAEAD 根据头部派生子密钥并创建 AES-GCM
+func newStreamAEAD(key, salt []byte) (cipher.AEAD, error) {
+	if _, err := aes.NewCipher(key); err != nil {
+		return nil, err
+	}
+	subKey, err := hkdfKey(sha256.New, key, salt, "gaes stream", len(key))
+	if err != nil {
+		return nil, err
+	}
+	return newGCM(subKey)
+}
+
+// streamNonce nonce前缀 || 分段序号 || 最后一段标记
+func streamNonce(dst, prefix []byte, counter uint32, last bool) []byte {
+	copy(dst, prefix)
+	binary.BigEndian.PutUint32(dst[streamPrefixSize:], counter)
+	dst[streamPrefixSize+4] = 0
+	if last {
+		dst[streamPrefixSize+4] = 1
+	}
+	return dst
+}
+
+// encryptWriter 流式加密
+type encryptWriter struct {
+	w           io.Writer
+	aead        cipher.AEAD
+	header      []byte
+	segmentSize int
+	counter     uint32
+	nonce       []byte
+	buf         []byte
+	out         []byte
+	closed      bool
+	err         error
+}
+
+// NewEncryptWriter 返回流式加密的 io.WriteCloser，写入明文，密文写入 w
+// 必须调用 Close 写入最后一段，否则解密时会报 ErrTruncated；Close 不会关闭 w
+func NewEncryptWriter(w io.Writer, key []byte) (io.WriteCloser, error) {
+	return newEncryptWriter(w, key, streamSegmentSize)
+}
+
+func newEncryptWriter(w io.Writer, key []byte, segmentSize int) (*encryptWriter, error) {
+	header := make([]byte, streamHeaderSize)
+	header[0] = formatStream
+	binary.BigEndian.PutUint32(header[1:5], uint32(segmentSize))
+	if _, err := io.ReadFull(rand.Reader, header[5:]); err != nil {
+		return nil, err
+	}
+	aead, err := newStreamAEAD(key, header[5:5+streamSaltSize])
+	if err != nil {
+		return nil, err
+	}
+	if _, err := w.Write(header); err != nil {
+		return nil, err
+	}
+	return &encryptWriter{
+		w:           w,
+		aead:        aead,
+		header:      header,
+		segmentSize: segmentSize,
+		nonce:       make([]byte, aead.NonceSize()),
+		buf:         make([]byte, 0, segmentSize),
+		out:         make([]byte, 0, segmentSize+streamTagSize),
+	}, nil
+}
+
+// Write 写入明文，分段写满且还有后续数据时才加密输出，保证最后一段在 Close 时输出
+func (e *encryptWriter) Write(p []byte) (int, error) {
+	if e.err != nil {
+		return 0, e.err
+	}
+	if e.closed {
+		return 0, errors.New("gaes: 写入已关闭的加密流")
+	}
+	n := 0
+	for len(p) > 0 {
+		if len(e.buf) == e.segmentSize {
+			if err := e.flush(false); err != nil {
+				return n, err
+			}
+		}
+		k := copy(e.buf[len(e.buf):e.segmentSize], p)
+		e.buf = e.buf[:len(e.buf)+k]
+		p = p[k:]
+		n += k
+	}
+	return n, nil
+}
+
+// Close 加密并写入最后一段
+func (e *encryptWriter) Close() error {
+	if e.closed {
+		return e.err
+	}
+	e.closed = true
+	if e.err != nil {
+		return e.err
+	}
+	return e.flush(true)
+}
+
+// flush 加密当前分段并写出
+func (e *encryptWriter) flush(last bool) error {
+	if e.counter == ^uint32(0) && !last {
+		e.err = errors.New("gaes: 加密流分段数超出上限")
+		return e.err
+	}
+	nonce := streamNonce(e.nonce, e.header[5+streamSaltSize:], e.counter, last)
+	e.out = e.aead.Seal(e.out[:0], nonce, e.buf, e.header)
+	if _, err := e.w.Write(e.out); err != nil {
+		e.err = err
+		return err
+	}
+	e.counter++
+	e.buf = e.buf[:0]
+	return nil
+}
+
+// decryptReader 流式解密
+type decryptReader struct {
+	r           io.Reader
+	aead        cipher.AEAD
+	header      []byte
+	segmentSize int
+	counter     uint32
+	nonce       []byte
+	in          []byte
+	pending     int
+	plain       []byte
+	buf         []byte
+	eof         bool
+	err         error
+}
+
+// NewDecryptReader 返回流式解密的 io.Reader，从 r 读取 NewEncryptWriter 生成的密文
+// 分段被篡改返回 ErrAuthentication，流被截断返回 ErrTruncated；
+// 每段校验通过后才返回该段明文，因此出错前已读到的明文可能不完整
+func NewDecryptReader(r io.Reader, key []byte) (io.Reader, error) {
+	header := make([]byte, streamHeaderSize)
+	if _, err := io.ReadFull(r, header); err != nil {
+		if err == io.EOF || err == io.ErrUnexpectedEOF {
+			return nil, ErrTruncated
+		}
+		return nil, err
+	}
+	if header[0] != formatStream {
+		return nil, ErrUnknownFormat
+	}
+	segmentSize := int(binary.BigEndian.Uint32(header[1:5]))
+	if segmentSize <= 0 || segmentSize > streamMaxSegmentSize {
+		return nil, ErrUnknownFormat
+	}
+	aead, err := newStreamAEAD(key, header[5:5+streamSaltSize])
+	if err != nil {
+		return nil, err
+	}
+	return &decryptReader{
+		r:           r,
+		aead:        aead,
+		header:      header,
+		segmentSize: segmentSize,
+		nonce:       make([]byte, aead.NonceSize()),
+		in:          make([]byte, segmentSize+streamTagSize+1),
+		buf:         make([]byte, 0, segmentSize),
+	}, nil
+}
+
+// Read 读取解密后的明文
+func (d *decryptReader) Read(p []byte) (int, error) {
+	for len(d.plain) == 0 {
+		if d.err != nil {
+			return 0, d.err
+		}
+		if d.eof {
+			return 0, io.EOF
+		}
+		d.err = d.readSegment()
+	}
+	n := copy(p, d.plain)
+	d.plain = d.plain[n:]
+	return n, nil
+}
+
+// readSegment 读取并解密一个分段，多读 1 字节用于判断是否为最后一段
+func (d *decryptReader) readSegment() error {
+	encSize := d.segmentSize + streamTagSize
+	n, err := io.ReadFull(d.r, d.in[d.pending:])
+	total := d.pending + n
+	last := false
+	switch err {
+	case nil:
+	case io.EOF, io.ErrUnexpectedEOF:
+		last = true
+		encSize = total
+	default:
+		return err
+	}
+	if encSize < streamTagSize {
+		return ErrTruncated
+	}
+	prefix := d.header[5+streamSaltSize:]
+	segment := d.in[:encSize]
+	plain, err := d.aead.Open(d.buf[:0], streamNonce(d.nonce, prefix, d.counter, last), segment, d.header)
+	if err != nil {
+		// 以非最后一段能通过校验，说明后续分段被删除
+		if last {
+			if _, err := d.aead.Open(d.buf[:0], streamNonce(d.nonce, prefix, d.counter, false), segment, d.header); err == nil {
+				return ErrTruncated
+			}
+		}
+		return ErrAuthentication
+	}
+	d.plain = plain
+	d.counter++
+	if last {
+		d.eof = true
+		d.pending = 0
+		return nil
+	}
+	if d.counter == 0 {
+		return errors.New("gaes: 解密流分段数超出上限")
+	}
+	d.in[0] = d.in[encSize]
+	d.pending = 1
+	return nil
+}
@@ -0,0 +1,129 @@
+package gaes
+
+import (
+	"bytes"
+	"crypto/rand"
+	"errors"
+	"io"
+	"testing"
+	"testing/iotest"
+)
+
+// streamEncrypt 使用指定分段大小加密
+func streamEncrypt(t *testing.T, data, key []byte, segmentSize int) []byte {
+	var buf bytes.Buffer
+	w, err := newEncryptWriter(&buf, key, segmentSize)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// 分多次写入，覆盖跨分段的情况
+	for len(data) > 0 {
+		n := 7
+		if n > len(data) {
+			n = len(data)
+		}
+		if _, err := w.Write(data[:n]); err != nil {
+			t.Fatal(err)
+		}
+		data = data[n:]
+	}
+	if err := w.Close(); err != nil {
+		t.Fatal(err)
+	}
+	return buf.Bytes()
+}
+
+func streamDecrypt(data, key []byte) ([]byte, error) {
+	r, err := NewDecryptReader(iotest.HalfReader(bytes.NewReader(data)), key)
+	if err != nil {
+		return nil, err
+	}
+	return io.ReadAll(r)
+}
+
+func TestStream(t *testing.T) {
+	key := []byte("1111111111111111")
+	for _, size := range []int{0, 1, 31, 32, 33, 64, 100} {
+		data := make([]byte, size)
+		rand.Read(data)
+		ed := streamEncrypt(t, data, key, 32)
+		dd, err := streamDecrypt(ed, key)
+		if err != nil {
+			t.Errorf("size %d: %v", size, err)
+			continue
+		}
+		if !bytes.Equal(dd, data) {
+			t.Errorf("size %d: 解密结果不一致", size)
+		}
+	}
+}
+
+func TestStreamDefaultSegment(t *testing.T) {
+	key := []byte("11111111111111111111111111111111")
+	data := make([]byte, 3*streamSegmentSize+123)
+	rand.Read(data)
+	var buf bytes.Buffer
+	w, err := NewEncryptWriter(&buf, key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := io.Copy(w, bytes.NewReader(data)); err != nil {
+		t.Fatal(err)
+	}
+	if err := w.Close(); err != nil {
+		t.Fatal(err)
+	}
+	dd, err := streamDecrypt(buf.Bytes(), key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(dd, data) {
+		t.Error("解密结果不一致")
+	}
+}
+
+func TestStreamTampered(t *testing.T) {
+	key := []byte("1111111111111111")
+	data := bytes.Repeat([]byte("0123456789"), 10)
+	ed := streamEncrypt(t, data, key, 32)
+	encSeg := 32 + streamTagSize
+	body := ed[streamHeaderSize:]
+
+	// 删除最后一段
+	truncated := ed[:streamHeaderSize+3*encSeg]
+	if _, err := streamDecrypt(truncated, key); !errors.Is(err, ErrTruncated) {
+		t.Errorf("删除最后一段应返回 ErrTruncated, got %v", err)
+	}
+	// 只有头部
+	if _, err := streamDecrypt(ed[:streamHeaderSize], key); !errors.Is(err, ErrTruncated) {
+		t.Errorf("只有头部应返回 ErrTruncated, got %v", err)
+	}
+	// 头部不完整
+	if _, err := streamDecrypt(ed[:10], key); !errors.Is(err, ErrTruncated) {
+		t.Errorf("头部不完整应返回 ErrTruncated, got %v", err)
+	}
+	// 分段中间截断
+	if _, err := streamDecrypt(ed[:len(ed)-3], key); !errors.Is(err, ErrAuthentication) {
+		t.Errorf("分段中间截断应认证失败, got %v", err)
+	}
+	// 交换前两段
+	swapped := append([]byte(nil), ed[:streamHeaderSize]...)
+	swapped = append(swapped, body[encSeg:2*encSeg]...)
+	swapped = append(swapped, body[:encSeg]...)
+	swapped = append(swapped, body[2*encSeg:]...)
+	if _, err := streamDecrypt(swapped, key); !errors.Is(err, ErrAuthentication) {
+		t.Errorf("分段重排应认证失败, got %v", err)
+	}
+	// 篡改头部或分段
+	for _, i := range []int{5, streamHeaderSize - 1, streamHeaderSize + 1, len(ed) - 1} {
+		bad := append([]byte(nil), ed...)
+		bad[i] ^= 0x01
+		if _, err := streamDecrypt(bad, key); !errors.Is(err, ErrAuthentication) {
+			t.Errorf("篡改第 %d 字节应认证失败, got %v", i, err)
+		}
+	}
+	// 错误密钥
+	if _, err := streamDecrypt(ed, []byte("2222222222222222")); !errors.Is(err, ErrAuthentication) {
+		t.Errorf("错误密钥应认证失败, got %v", err)
+	}
+}
@@ -5,10 +5,11 @@ go 1.21.4
 require (
 	github.com/golang/freetype v0.0.0-20170609003504-e2365dfdc4a0
 	github.com/tjfoc/gmsm v1.4.1
+	golang.org/x/crypto v0.23.0
+	golang.org/x/term v0.20.0
 )
 
 require (
-	golang.org/x/crypto v0.23.0 // indirect
 	golang.org/x/image v0.16.0 // indirect
 	golang.org/x/sys v0.20.0 // indirect
 )
@@ -0,0 +1,168 @@
+package gsm2
+
+import (
+	"crypto/rand"
+	"encoding/asn1"
+	"errors"
+	"fmt"
+	"math/big"
+
+	"github.com/tjfoc/gmsm/sm2"
+)
+
+// SM2 公钥加密的密文格式：
+//  C1 为随机点 04 || x1 || y1（65 字节），C3 为 SM3 杂凑值（32 字节），C2 为与明文等长的密文
+//  1、ASN.1（GM/T 0009，默认）：SEQUENCE { x INTEGER, y INTEGER, hash OCTET STRING, cipherText OCTET STRING }
+//  2、原始拼接 C1C3C2（GM/T 0003-2012），BouncyCastle 新版本和多数密码机使用
+//  3、原始拼接 C1C2C3（旧标准草案），部分老系统使用
+// 原始格式可以选择去掉 C1 的 04 前缀。解密时自动识别以上所有格式并转换为 C1C3C2 交给 tjfoc/gmsm 解密，以 C3 校验结果为准
+
+// CipherLayout 原始格式中 C1、C2、C3 的排列顺序
+type CipherLayout int
+
+const (
+	// C1C3C2 GM/T 0003-2012 规定的顺序，默认
+	C1C3C2 CipherLayout = iota
+	// C1C2C3 旧标准草案的顺序
+	C1C2C3
+)
+
+// CipherEncoding 密文编码
+type CipherEncoding int
+
+const (
+	// EncodingASN1 GM/T 0009 ASN.1 编码，与 PublicKeyEncrypt 默认输出相同，各部分顺序固定为 C1C3C2
+	EncodingASN1 CipherEncoding = iota
+	// EncodingRaw 按 Layout 直接拼接
+	EncodingRaw
+)
+
+// EncryptOptions 加密输出格式，零值为 ASN.1 编码
+type EncryptOptions struct {
+	// Encoding 编码方式，默认 EncodingASN1
+	Encoding CipherEncoding
+	// Layout 原始格式的排列顺序，仅 EncodingRaw 使用
+	Layout CipherLayout
+	// OmitPrefix 原始格式中去掉 C1 的 04 前缀
+	OmitPrefix bool
+}
+
+const (
+	sm2CoordSize = 32
+	sm2C1Size    = 2 * sm2CoordSize
+	sm2C3Size    = 32
+)
+
+// ErrDecryption 解密失败，密文格式错误、被篡改或私钥不匹配
+var ErrDecryption = errors.New("gsm2: 解密失败，密文格式错误或私钥不匹配")
+
+// ErrEmptyPlaintext SM2 不能加密空明文
+var ErrEmptyPlaintext = errors.New("gsm2: 明文不能为空")
+
+// sm2Cipher GM/T 0009 密文结构
+type sm2Cipher struct {
+	X, Y       *big.Int
+	Hash       []byte
+	CipherText []byte
+}
+
+// sm2Parts 拆分后的密文，c1 为不带前缀的 x1 || y1
+type sm2Parts struct {
+	c1, c3, c2 []byte
+}
+
+// EncryptWithOptions 按 opts 指定的格式加密
+func (k *PublicKey) EncryptWithOptions(originalText []byte, opts EncryptOptions) ([]byte, error) {
+	if len(originalText) == 0 {
+		return nil, ErrEmptyPlaintext
+	}
+	// tjfoc/gmsm 输出 04 || C1 || C3 || C2
+	c, err := sm2.Encrypt(k.key, originalText, rand.Reader, sm2.C1C3C2)
+	if err != nil {
+		return nil, err
+	}
+	p := sm2Parts{c1: c[1 : 1+sm2C1Size], c3: c[1+sm2C1Size : 1+sm2C1Size+sm2C3Size], c2: c[1+sm2C1Size+sm2C3Size:]}
+	switch opts.Encoding {
+	case EncodingASN1:
+		return asn1.Marshal(sm2Cipher{
+			X:          new(big.Int).SetBytes(p.c1[:sm2CoordSize]),
+			Y:          new(big.Int).SetBytes(p.c1[sm2CoordSize:]),
+			Hash:       p.c3,
+			CipherText: p.c2,
+		})
+	case EncodingRaw:
+		out := make([]byte, 0, len(c))
+		if !opts.OmitPrefix {
+			out = append(out, 0x04)
+		}
+		out = append(out, p.c1...)
+		switch opts.Layout {
+		case C1C3C2:
+			return append(append(out, p.c3...), p.c2...), nil
+		case C1C2C3:
+			return append(append(out, p.c2...), p.c3...), nil
+		}
+		return nil, fmt.Errorf("gsm2: 未知的密文排列 %d", opts.Layout)
+	}
+	return nil, fmt.Errorf("gsm2: 未知的密文编码 %d", opts.Encoding)
+}
+
+// candidates 列出密文可能的解析方式，ASN.1 优先
+func candidates(data []byte) []sm2Parts {
+	var parts []sm2Parts
+	var c sm2Cipher
+	if rest, err := asn1.Unmarshal(data, &c); err == nil && len(rest) == 0 &&
+		c.X.Sign() >= 0 && c.Y.Sign() >= 0 && c.X.BitLen() <= 256 && c.Y.BitLen() <= 256 {
+		c1 := make([]byte, sm2C1Size)
+		c.X.FillBytes(c1[:sm2CoordSize])
+		c.Y.FillBytes(c1[sm2CoordSize:])
+		parts = append(parts, sm2Parts{c1: c1, c3: c.Hash, c2: c.CipherText})
+	}
+	raw := func(b []byte) {
+		if len(b) <= sm2C1Size+sm2C3Size {
+			return
+		}
+		c1, rest := b[:sm2C1Size], b[sm2C1Size:]
+		parts = append(parts,
+			sm2Parts{c1: c1, c3: rest[:sm2C3Size], c2: rest[sm2C3Size:]},
+			sm2Parts{c1: c1, c2: rest[:len(rest)-sm2C3Size], c3: rest[len(rest)-sm2C3Size:]})
+	}
+	if len(data) > 0 && data[0] == 0x04 {
+		raw(data[1:])
+	}
+	raw(data)
+	return parts
+}
+
+// decryptSM2 自动识别密文格式，转换为 tjfoc/gmsm 的 04 || C1 || C3 || C2 后调用 sm2.Decrypt，
+// 依次尝试各候选格式，以 C3 校验通过的为准
+func decryptSM2(priv *sm2.PrivateKey, data []byte) ([]byte, error) {
+	curve := priv.Curve
+	for _, p := range candidates(data) {
+		// tjfoc/gmsm 不检查长度和 C1，不合法的输入会 panic 或遭受无效曲线攻击，需提前过滤
+		if len(p.c1) != sm2C1Size || len(p.c3) != sm2C3Size || len(p.c2) == 0 {
+			continue
+		}
+		x1 := new(big.Int).SetBytes(p.c1[:sm2CoordSize])
+		y1 := new(big.Int).SetBytes(p.c1[sm2CoordSize:])
+		if !curve.IsOnCurve(x1, y1) {
+			continue
+		}
+		c := make([]byte, 0, 1+sm2C1Size+sm2C3Size+len(p.c2))
+		c = append(c, 0x04)
+		c = append(append(append(c, p.c1...), p.c3...), p.c2...)
+		if m, err := sm2.Decrypt(priv, c, sm2.C1C3C2); err == nil {
+			return m, nil
+		}
+	}
+	return nil, ErrDecryption
+}
+
+// PublicKeyEncryptWithOptions 使用 PEM 公钥按 opts 指定的格式加密
+func PublicKeyEncryptWithOptions(originalText, publicKey []byte, opts EncryptOptions) ([]byte, error) {
+	pub, err := ParsePublicKeyPEM(publicKey)
+	if err != nil {
+		return nil, err
+	}
+	return pub.EncryptWithOptions(originalText, opts)
+}
@@ -0,0 +1,103 @@
+package gsm2
+
+import (
+	"bytes"
+	"crypto/rand"
+	"errors"
+	"testing"
+
+	"github.com/tjfoc/gmsm/sm2"
+)
+
+func TestEncryptOptions(t *testing.T) {
+	private, public, err := GerenateSM2Key(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	priv, _ := ParsePrivateKeyPEM(private, nil)
+	pub := priv.Public()
+	text := []byte("bank partner message")
+	cases := []struct {
+		name   string
+		opts   EncryptOptions
+		length int
+	}{
+		{"ASN.1", EncryptOptions{}, 0},
+		{"C1C3C2", EncryptOptions{Encoding: EncodingRaw}, 97 + len(text)},
+		{"C1C2C3", EncryptOptions{Encoding: EncodingRaw, Layout: C1C2C3}, 97 + len(text)},
+		{"C1C3C2 无前缀", EncryptOptions{Encoding: EncodingRaw, OmitPrefix: true}, 96 + len(text)},
+		{"C1C2C3 无前缀", EncryptOptions{Encoding: EncodingRaw, Layout: C1C2C3, OmitPrefix: true}, 96 + len(text)},
+	}
+	for _, c := range cases {
+		// 多次加密覆盖 x1 首字节为 0x04、0x30 等容易误判的情况
+		for i := 0; i < 20; i++ {
+			ct, err := PublicKeyEncryptWithOptions(text, public, c.opts)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if c.length > 0 && len(ct) != c.length {
+				t.Errorf("%s: 密文长度 %d", c.name, len(ct))
+			}
+			if c.opts.Encoding == EncodingRaw && !c.opts.OmitPrefix && ct[0] != 0x04 {
+				t.Errorf("%s: 缺少 04 前缀", c.name)
+			}
+			if pt, err := PrivateKeyDecrypt(ct, private, nil); err != nil || !bytes.Equal(pt, text) {
+				t.Errorf("%s: 解密失败 %q %v", c.name, pt, err)
+			}
+			if pt, err := priv.Decrypt(ct); err != nil || !bytes.Equal(pt, text) {
+				t.Errorf("%s: PrivateKey 解密失败 %q %v", c.name, pt, err)
+			}
+		}
+	}
+
+	// 与 tjfoc/gmsm 互通
+	for _, mode := range []int{sm2.C1C3C2, sm2.C1C2C3} {
+		ct, _ := sm2.Encrypt(pub.key, text, rand.Reader, mode)
+		if pt, err := priv.Decrypt(ct); err != nil || !bytes.Equal(pt, text) {
+			t.Errorf("mode %d: 解密 tjfoc/gmsm 密文失败 %v", mode, err)
+		}
+	}
+	ct, _ := pub.EncryptWithOptions(text, EncryptOptions{Encoding: EncodingRaw, Layout: C1C2C3})
+	if pt, err := sm2.Decrypt(priv.key, ct, sm2.C1C2C3); err != nil || !bytes.Equal(pt, text) {
+		t.Errorf("tjfoc/gmsm 解密 C1C2C3 失败 %v", err)
+	}
+	ct, _ = pub.Encrypt(text)
+	if pt, err := priv.key.DecryptAsn1(ct); err != nil || !bytes.Equal(pt, text) {
+		t.Errorf("tjfoc/gmsm 解密 ASN.1 失败 %v", err)
+	}
+
+	if _, err := pub.Encrypt(nil); !errors.Is(err, ErrEmptyPlaintext) {
+		t.Errorf("空明文应返回 ErrEmptyPlaintext, got %v", err)
+	}
+	if _, err := pub.EncryptWithOptions(text, EncryptOptions{Encoding: 9}); err == nil {
+		t.Error("未知编码应返回错误")
+	}
+}
+
+func TestDecryptCorrupt(t *testing.T) {
+	priv, err := NewPrivateKey(bytes.Repeat([]byte{1}, 32))
+	if err != nil {
+		t.Fatal(err)
+	}
+	other, _ := NewPrivateKey(bytes.Repeat([]byte{2}, 32))
+	for _, opts := range []EncryptOptions{{}, {Encoding: EncodingRaw}, {Encoding: EncodingRaw, Layout: C1C2C3, OmitPrefix: true}} {
+		ct, err := priv.Public().EncryptWithOptions([]byte("123"), opts)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, err := other.Decrypt(ct); !errors.Is(err, ErrDecryption) {
+			t.Errorf("私钥不匹配应返回 ErrDecryption, got %v", err)
+		}
+		// 截断和篡改都不能 panic，且必须返回 ErrDecryption
+		for i := 0; i < len(ct); i++ {
+			if _, err := priv.Decrypt(ct[:i]); !errors.Is(err, ErrDecryption) {
+				t.Errorf("截断到 %d 字节应返回 ErrDecryption, got %v", i, err)
+			}
+			bad := append([]byte(nil), ct...)
+			bad[i] ^= 0x01
+			if _, err := priv.Decrypt(bad); !errors.Is(err, ErrDecryption) {
+				t.Errorf("篡改第 %d 字节应返回 ErrDecryption, got %v", i, err)
+			}
+		}
+	}
+}
@@ -0,0 +1,174 @@
+package gsm2
+
+import (
+	"crypto/rand"
+	"crypto/subtle"
+	"errors"
+	"math/big"
+
+	"github.com/tjfoc/gmsm/sm2"
+)
+
+// SM2 密钥交换协议（GM/T 0003.3），双方各自持有长期 SM2 密钥对并知道对方的公钥和用户 ID：
+//
+//	A（发起方）                                  B（响应方）
+//	a := NewKeyExchangeInitiator(...)
+//	ra := a.Ephemeral()              ── RA ──>
+//	                                             b := NewKeyExchangeResponder(...)
+//	                                 <── RB, SB ─ rb, sb, keyB, err := b.Respond(ra)
+//	keyA, sa, err := a.Finish(rb, sb)
+//	                                 ── SA ──>   err := b.Confirm(sa)
+//
+// SB、SA 为可选的确认值，用于确认对方确实计算出了相同的密钥，不需要确认时 Finish 的 sb 传 nil，
+// 响应方不调用 Confirm。协商出的密钥长度由 keyLen 指定，16 字节可直接用于 SM4，32 字节用于 AES-256。
+// 协商由 tjfoc/gmsm 的 KeyExchangeA/KeyExchangeB 完成，密钥和确认值与其一致。
+// 每个 KeyExchangeInitiator/KeyExchangeResponder 只能用于一次协商，对方临时公钥校验通过后临时私钥即作废，
+// 再次调用 Finish/Respond 返回 ErrExchangeDone
+
+// ErrKeyConfirmation 对方的确认值与本方计算结果不一致
+var ErrKeyConfirmation = errors.New("gsm2: 密钥确认失败")
+
+// ErrInvalidEphemeral 对方的临时公钥无效
+var ErrInvalidEphemeral = errors.New("gsm2: 临时公钥无效")
+
+// ErrExchangeDone 本方临时密钥已用于一次协商，不能重复使用
+var ErrExchangeDone = errors.New("gsm2: 密钥交换已完成，临时密钥不能重复使用")
+
+// keyExchange 发起方和响应方共用的状态，ida、idb 分别为发起方和响应方的用户 ID
+type keyExchange struct {
+	priv      *sm2.PrivateKey
+	peer      *sm2.PublicKey
+	eph       *sm2.PrivateKey
+	ida, idb  []byte
+	keyLen    int
+	initiator bool
+	done      bool
+}
+
+func newKeyExchange(priv *PrivateKey, uid []byte, peer *PublicKey, peerUID []byte, keyLen int, initiator bool) (*keyExchange, error) {
+	if keyLen <= 0 {
+		return nil, errors.New("gsm2: 协商密钥长度必须大于 0")
+	}
+	// tjfoc/gmsm 不会为空 ID 使用默认值，这里先替换
+	ids := [2][]byte{uid, peerUID}
+	for i, id := range ids {
+		if len(id) == 0 {
+			ids[i] = DefaultUserID
+		} else if len(id) > maxUserIDLen {
+			return nil, ErrUserIDTooLong
+		}
+	}
+	eph, err := sm2.GenerateKey(rand.Reader)
+	if err != nil {
+		return nil, err
+	}
+	kx := &keyExchange{priv: priv.key, peer: peer.key, eph: eph, ida: ids[0], idb: ids[1], keyLen: keyLen, initiator: initiator}
+	if !initiator {
+		kx.ida, kx.idb = kx.idb, kx.ida
+	}
+	return kx, nil
+}
+
+// ephemeral 返回临时公钥 04 || x || y
+func (kx *keyExchange) ephemeral() []byte {
+	out := make([]byte, 1+sm2C1Size)
+	out[0] = 0x04
+	kx.eph.X.FillBytes(out[1 : 1+sm2CoordSize])
+	kx.eph.Y.FillBytes(out[1+sm2CoordSize:])
+	return out
+}
+
+// agree 校验对方临时公钥后调用 tjfoc/gmsm 协商，返回协商密钥和确认值 S1（tag 0x02）、S2（tag 0x03）
+func (kx *keyExchange) agree(peerEphemeral []byte) (key, s1, s2 []byte, err error) {
+	if kx.done {
+		return nil, nil, nil, ErrExchangeDone
+	}
+	if len(peerEphemeral) != 1+sm2C1Size || peerEphemeral[0] != 0x04 {
+		return nil, nil, nil, ErrInvalidEphemeral
+	}
+	r := &sm2.PublicKey{
+		Curve: kx.priv.Curve,
+		X:     new(big.Int).SetBytes(peerEphemeral[1 : 1+sm2CoordSize]),
+		Y:     new(big.Int).SetBytes(peerEphemeral[1+sm2CoordSize:]),
+	}
+	if !r.Curve.IsOnCurve(r.X, r.Y) {
+		return nil, nil, nil, ErrInvalidEphemeral
+	}
+	// 临时私钥参与计算后不再使用，失败时也不能重试
+	kx.done = true
+	if kx.initiator {
+		key, s1, s2, err = sm2.KeyExchangeA(kx.keyLen, kx.ida, kx.idb, kx.priv, kx.peer, kx.eph, r)
+	} else {
+		key, s1, s2, err = sm2.KeyExchangeB(kx.keyLen, kx.ida, kx.idb, kx.priv, kx.peer, kx.eph, r)
+	}
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	return key, s1, s2, nil
+}
+
+// KeyExchangeInitiator 密钥交换发起方（A）
+type KeyExchangeInitiator struct {
+	kx *keyExchange
+}
+
+// NewKeyExchangeInitiator 创建发起方，uid 为本方用户 ID，peer、peerUID 为响应方的公钥和用户 ID，
+// 用户 ID 为空时使用 DefaultUserID，keyLen 为协商密钥的字节数
+func NewKeyExchangeInitiator(priv *PrivateKey, uid []byte, peer *PublicKey, peerUID []byte, keyLen int) (*KeyExchangeInitiator, error) {
+	kx, err := newKeyExchange(priv, uid, peer, peerUID, keyLen, true)
+	if err != nil {
+		return nil, err
+	}
+	return &KeyExchangeInitiator{kx: kx}, nil
+}
+
+// Ephemeral 返回发送给响应方的临时公钥 RA（04 || x || y）
+func (a *KeyExchangeInitiator) Ephemeral() []byte {
+	return a.kx.ephemeral()
+}
+
+// Finish 根据响应方的临时公钥 RB 计算协商密钥，只能调用一次
+// sb 不为 nil 时校验响应方的确认值，不一致返回 ErrKeyConfirmation；返回的 sa 发送给响应方用于 Confirm
+func (a *KeyExchangeInitiator) Finish(rb, sb []byte) (key, sa []byte, err error) {
+	key, s1, s2, err := a.kx.agree(rb)
+	if err != nil {
+		return nil, nil, err
+	}
+	if sb != nil && subtle.ConstantTimeCompare(s1, sb) != 1 {
+		return nil, nil, ErrKeyConfirmation
+	}
+	return key, s2, nil
+}
+
+// KeyExchangeResponder 密钥交换响应方（B）
+type KeyExchangeResponder struct {
+	kx *keyExchange
+	s2 []byte
+}
+
+// NewKeyExchangeResponder 创建响应方，参数含义同 NewKeyExchangeInitiator，peer、peerUID 为发起方的公钥和用户 ID
+func NewKeyExchangeResponder(priv *PrivateKey, uid []byte, peer *PublicKey, peerUID []byte, keyLen int) (*KeyExchangeResponder, error) {
+	kx, err := newKeyExchange(priv, uid, peer, peerUID, keyLen, false)
+	if err != nil {
+		return nil, err
+	}
+	return &KeyExchangeResponder{kx: kx}, nil
+}
+
+// Respond 根据发起方的临时公钥 RA 计算协商密钥，返回发送给发起方的临时公钥 RB 和确认值 SB，只能调用一次
+func (b *KeyExchangeResponder) Respond(ra []byte) (rb, sb, key []byte, err error) {
+	key, s1, s2, err := b.kx.agree(ra)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	b.s2 = s2
+	return b.kx.ephemeral(), s1, key, nil
+}
+
+// Confirm 校验发起方的确认值 SA，不一致返回 ErrKeyConfirmation，必须在 Respond 之后调用
+func (b *KeyExchangeResponder) Confirm(sa []byte) error {
+	if b.s2 == nil || subtle.ConstantTimeCompare(b.s2, sa) != 1 {
+		return ErrKeyConfirmation
+	}
+	return nil
+}
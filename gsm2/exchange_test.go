@@ -0,0 +1,236 @@
+package gsm2
+
+import (
+	"bytes"
+	"encoding/hex"
+	"errors"
+	"testing"
+
+	"github.com/tjfoc/gmsm/sm2"
+)
+
+// exchangeParties 生成双方的密钥对
+func exchangeParties(t *testing.T) (a, b *PrivateKey) {
+	t.Helper()
+	for _, p := range []**PrivateKey{&a, &b} {
+		private, _, err := GerenateSM2Key(nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if *p, err = ParsePrivateKeyPEM(private, nil); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return a, b
+}
+
+func TestKeyExchange(t *testing.T) {
+	a, b := exchangeParties(t)
+	ida, idb := []byte("alice@example.com"), []byte("bob@example.com")
+	for _, keyLen := range []int{16, 32, 48} {
+		ia, err := NewKeyExchangeInitiator(a, ida, b.Public(), idb, keyLen)
+		if err != nil {
+			t.Fatal(err)
+		}
+		rs, err := NewKeyExchangeResponder(b, idb, a.Public(), ida, keyLen)
+		if err != nil {
+			t.Fatal(err)
+		}
+		rb, sb, keyB, err := rs.Respond(ia.Ephemeral())
+		if err != nil {
+			t.Fatal(err)
+		}
+		keyA, sa, err := ia.Finish(rb, sb)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := rs.Confirm(sa); err != nil {
+			t.Fatal(err)
+		}
+		if len(keyA) != keyLen || !bytes.Equal(keyA, keyB) {
+			t.Fatalf("keyLen=%d 双方密钥不一致 %x %x", keyLen, keyA, keyB)
+		}
+	}
+}
+
+func TestKeyExchangeDefaultUserID(t *testing.T) {
+	a, b := exchangeParties(t)
+	ia, _ := NewKeyExchangeInitiator(a, nil, b.Public(), nil, 16)
+	rs, _ := NewKeyExchangeResponder(b, DefaultUserID, a.Public(), DefaultUserID, 16)
+	rb, sb, keyB, err := rs.Respond(ia.Ephemeral())
+	if err != nil {
+		t.Fatal(err)
+	}
+	// 不校验确认值
+	keyA, _, err := ia.Finish(rb, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(keyA, keyB) {
+		t.Fatal("双方密钥不一致")
+	}
+	if len(sb) != 32 {
+		t.Fatalf("SB 长度 %d", len(sb))
+	}
+}
+
+// 固定私钥和临时私钥，协商密钥与 tjfoc/gmsm 直接计算的结果一致
+func TestKeyExchangeVector(t *testing.T) {
+	hexKey := func(s string) *PrivateKey {
+		k, err := ParsePrivateKeyHex(s)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return k
+	}
+	a := hexKey("81eb26e941bb5af16df116495f90695272ae2cd63d6c4ae1678418be48230029")
+	b := hexKey("785129917d45a9ea5437a59356b82338eaadda6ceb199088f14ae10defa229b5")
+	ra := hexKey("d4de15474db74d06491c440d305e012400990f3e390c7e87153c12db2ea60bb3")
+	rb := hexKey("7e07124814b309489125eaed101113164ebf0f3458c5bd88335c1f9d596243d6")
+	ida, idb := []byte("ALICE123@YAHOO.COM"), []byte("BILL456@YAHOO.COM")
+	want, _ := hex.DecodeString("3c1362830b075a6f891dbf651997bf50")
+
+	ia, _ := NewKeyExchangeInitiator(a, ida, b.Public(), idb, 16)
+	rs, _ := NewKeyExchangeResponder(b, idb, a.Public(), ida, 16)
+	ia.kx.eph, rs.kx.eph = ra.key, rb.key
+	RB, sb, keyB, err := rs.Respond(ia.Ephemeral())
+	if err != nil {
+		t.Fatal(err)
+	}
+	keyA, sa, err := ia.Finish(RB, sb)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := rs.Confirm(sa); err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(keyA, want) || !bytes.Equal(keyB, want) {
+		t.Fatalf("密钥 %x %x，期望 %x", keyA, keyB, want)
+	}
+
+	tjfocKey, _, _, err := sm2.KeyExchangeA(16, ida, idb, a.key, &b.key.PublicKey, ra.key, &rb.key.PublicKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(tjfocKey, want) {
+		t.Fatalf("tjfoc 密钥 %x", tjfocKey)
+	}
+}
+
+func TestKeyExchangeConfirmation(t *testing.T) {
+	a, b := exchangeParties(t)
+	ia, _ := NewKeyExchangeInitiator(a, nil, b.Public(), nil, 16)
+	rs, _ := NewKeyExchangeResponder(b, nil, a.Public(), nil, 16)
+	if err := rs.Confirm(make([]byte, 32)); !errors.Is(err, ErrKeyConfirmation) {
+		t.Fatalf("Respond 之前 Confirm: %v", err)
+	}
+	rb, sb, _, err := rs.Respond(ia.Ephemeral())
+	if err != nil {
+		t.Fatal(err)
+	}
+	bad := append([]byte(nil), sb...)
+	bad[0] ^= 1
+	if _, _, err := ia.Finish(rb, bad); !errors.Is(err, ErrKeyConfirmation) {
+		t.Fatalf("篡改 SB: %v", err)
+	}
+	// 确认失败后临时密钥也已作废
+	if _, _, err := ia.Finish(rb, sb); !errors.Is(err, ErrExchangeDone) {
+		t.Fatalf("确认失败后重试: %v", err)
+	}
+
+	ia, _ = NewKeyExchangeInitiator(a, nil, b.Public(), nil, 16)
+	rs, _ = NewKeyExchangeResponder(b, nil, a.Public(), nil, 16)
+	rb, sb, _, err = rs.Respond(ia.Ephemeral())
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, sa, err := ia.Finish(rb, sb)
+	if err != nil {
+		t.Fatal(err)
+	}
+	sa[len(sa)-1] ^= 1
+	if err := rs.Confirm(sa); !errors.Is(err, ErrKeyConfirmation) {
+		t.Fatalf("篡改 SA: %v", err)
+	}
+}
+
+func TestKeyExchangeMismatchedID(t *testing.T) {
+	a, b := exchangeParties(t)
+	rs, _ := NewKeyExchangeResponder(b, []byte("bob"), a.Public(), []byte("mallory"), 16)
+	ia, _ := NewKeyExchangeInitiator(a, []byte("alice"), b.Public(), []byte("bob"), 16)
+	rb, sb, keyB, err := rs.Respond(ia.Ephemeral())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, _, err := ia.Finish(rb, sb); !errors.Is(err, ErrKeyConfirmation) {
+		t.Fatalf("用户 ID 不一致: %v", err)
+	}
+
+	rs, _ = NewKeyExchangeResponder(b, []byte("bob"), a.Public(), []byte("mallory"), 16)
+	ia, _ = NewKeyExchangeInitiator(a, []byte("alice"), b.Public(), []byte("bob"), 16)
+	rb, _, keyB, err = rs.Respond(ia.Ephemeral())
+	if err != nil {
+		t.Fatal(err)
+	}
+	keyA, _, err := ia.Finish(rb, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if bytes.Equal(keyA, keyB) {
+		t.Fatal("用户 ID 不一致时密钥相同")
+	}
+}
+
+// 临时私钥只能参与一次协商
+func TestKeyExchangeSingleUse(t *testing.T) {
+	a, b := exchangeParties(t)
+	ia, _ := NewKeyExchangeInitiator(a, nil, b.Public(), nil, 16)
+	rs, _ := NewKeyExchangeResponder(b, nil, a.Public(), nil, 16)
+	ra := ia.Ephemeral()
+	rb, sb, _, err := rs.Respond(ra)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, _, _, err := rs.Respond(ra); !errors.Is(err, ErrExchangeDone) {
+		t.Errorf("重复 Respond: %v", err)
+	}
+	if _, _, err := ia.Finish(rb, sb); err != nil {
+		t.Fatal(err)
+	}
+	if _, _, err := ia.Finish(rb, sb); !errors.Is(err, ErrExchangeDone) {
+		t.Errorf("重复 Finish: %v", err)
+	}
+}
+
+func TestKeyExchangeInvalidEphemeral(t *testing.T) {
+	a, b := exchangeParties(t)
+	ia, _ := NewKeyExchangeInitiator(a, nil, b.Public(), nil, 16)
+	rs, _ := NewKeyExchangeResponder(b, nil, a.Public(), nil, 16)
+	ra := ia.Ephemeral()
+	offCurve := append([]byte(nil), ra...)
+	offCurve[len(offCurve)-1] ^= 1
+	cases := map[string][]byte{
+		"空":    nil,
+		"长度错误": ra[:64],
+		"无前缀":  append([]byte{0x02}, ra[1:]...),
+		"不在曲线": offCurve,
+	}
+	for name, r := range cases {
+		if _, _, _, err := rs.Respond(r); !errors.Is(err, ErrInvalidEphemeral) {
+			t.Errorf("%s: %v", name, err)
+		}
+		if _, _, err := ia.Finish(r, nil); !errors.Is(err, ErrInvalidEphemeral) {
+			t.Errorf("%s: %v", name, err)
+		}
+	}
+}
+
+func TestKeyExchangeKeyLen(t *testing.T) {
+	a, b := exchangeParties(t)
+	if _, err := NewKeyExchangeInitiator(a, nil, b.Public(), nil, 0); err == nil {
+		t.Fatal("keyLen=0 应返回错误")
+	}
+	if _, err := NewKeyExchangeResponder(b, nil, a.Public(), nil, -1); err == nil {
+		t.Fatal("keyLen=-1 应返回错误")
+	}
+}
@@ -0,0 +1,287 @@
+package gsm2
+
+import (
+	"crypto/elliptic"
+	"crypto/x509/pkix"
+	"encoding/asn1"
+	"encoding/hex"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"math/big"
+
+	"github.com/tjfoc/gmsm/sm2"
+	"github.com/tjfoc/gmsm/x509"
+)
+
+// ErrMalformedPEM PEM 无法解码或其中的密钥数据损坏
+var ErrMalformedPEM = errors.New("gsm2: PEM 格式错误或密钥数据损坏")
+
+// ErrWrongPassword 加密私钥的密码错误
+var ErrWrongPassword = errors.New("gsm2: 私钥密码错误")
+
+// ErrWrongKeyType 密钥不是 SM2 密钥，或把公钥当作私钥使用（反之亦然）
+var ErrWrongKeyType = errors.New("gsm2: 密钥类型错误，需要 SM2 密钥")
+
+var (
+	// oidECPublicKey 椭圆曲线公钥算法，SM2 密钥通过参数中的曲线 OID 区分
+	oidECPublicKey = asn1.ObjectIdentifier{1, 2, 840, 10045, 2, 1}
+	// oidSM2 SM2 曲线，部分实现也直接用作算法 OID
+	oidSM2 = asn1.ObjectIdentifier{1, 2, 156, 10197, 1, 301}
+)
+
+// pkcs8 PKCS#8 未加密私钥，只用于检查算法
+type pkcs8 struct {
+	Version    int
+	Algo       pkix.AlgorithmIdentifier
+	PrivateKey []byte
+}
+
+// pkixPublicKey SubjectPublicKeyInfo，只用于检查算法
+type pkixPublicKey struct {
+	Algo      pkix.AlgorithmIdentifier
+	BitString asn1.BitString
+}
+
+// isSM2Algorithm 判断算法标识是否为 SM2
+func isSM2Algorithm(algo pkix.AlgorithmIdentifier) bool {
+	if algo.Algorithm.Equal(oidSM2) {
+		return true
+	}
+	if !algo.Algorithm.Equal(oidECPublicKey) {
+		return false
+	}
+	var curve asn1.ObjectIdentifier
+	if _, err := asn1.Unmarshal(algo.Parameters.FullBytes, &curve); err != nil {
+		return false
+	}
+	return curve.Equal(oidSM2)
+}
+
+// decodePEM 解码 PEM，返回第一个块
+func decodePEM(data []byte) (*pem.Block, error) {
+	block, _ := pem.Decode(data)
+	if block == nil {
+		return nil, ErrMalformedPEM
+	}
+	return block, nil
+}
+
+// parsePrivateKey 解析 PEM 编码的 SM2 私钥，ENCRYPTED PRIVATE KEY 使用 pwd 解密
+func parsePrivateKey(data, pwd []byte) (*sm2.PrivateKey, error) {
+	block, err := decodePEM(data)
+	if err != nil {
+		return nil, err
+	}
+	switch block.Type {
+	case "ENCRYPTED PRIVATE KEY":
+		return parseEncryptedPrivateKey(block.Bytes, pwd)
+	case "PRIVATE KEY":
+		return parseUnencryptedPrivateKey(block.Bytes)
+	case "PUBLIC KEY", "RSA PRIVATE KEY", "EC PRIVATE KEY", "CERTIFICATE":
+		return nil, fmt.Errorf("%w: %s", ErrWrongKeyType, block.Type)
+	}
+	return nil, fmt.Errorf("%w: 未知的 PEM 类型 %s", ErrMalformedPEM, block.Type)
+}
+
+func parseUnencryptedPrivateKey(der []byte) (*sm2.PrivateKey, error) {
+	var key pkcs8
+	if _, err := asn1.Unmarshal(der, &key); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrMalformedPEM, err)
+	}
+	if !isSM2Algorithm(key.Algo) {
+		return nil, fmt.Errorf("%w: 算法 %s", ErrWrongKeyType, key.Algo.Algorithm)
+	}
+	priv, err := x509.ParseSm2PrivateKey(key.PrivateKey)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrMalformedPEM, err)
+	}
+	if priv.D.Sign() == 0 {
+		return nil, fmt.Errorf("%w: 私钥为 0", ErrMalformedPEM)
+	}
+	return priv, nil
+}
+
+// errIncorrectPassword tjfoc/gmsm 密码错误时的错误文本
+// 该错误由 errors.New 临时创建，没有导出的哨兵错误或类型可供 errors.Is 判断，只能比较文本，
+// TestIncorrectPasswordText 针对当前依赖版本校验该文本，升级 gmsm 后文本变化会使测试失败
+const errIncorrectPassword = "pkcs8: incorrect password"
+
+// parseEncryptedPrivateKey 解密 PKCS#8 加密私钥
+// tjfoc/gmsm 在 IV 或密文长度不合法时会 panic，这里转换为 ErrMalformedPEM
+func parseEncryptedPrivateKey(der, pwd []byte) (priv *sm2.PrivateKey, err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			priv, err = nil, fmt.Errorf("%w: %v", ErrMalformedPEM, r)
+		}
+	}()
+	// pwd 为 nil 时 tjfoc/gmsm 会按未加密私钥解析，空密码加密的私钥需要传入非 nil 的空切片
+	if pwd == nil {
+		pwd = []byte{}
+	}
+	priv, err = x509.ParsePKCS8EcryptedPrivateKey(der, pwd)
+	if err != nil {
+		if err.Error() == errIncorrectPassword {
+			return nil, ErrWrongPassword
+		}
+		return nil, fmt.Errorf("%w: %v", ErrMalformedPEM, err)
+	}
+	return priv, nil
+}
+
+// parsePublicKey 解析 PEM 编码的 SM2 公钥
+func parsePublicKey(data []byte) (*sm2.PublicKey, error) {
+	block, err := decodePEM(data)
+	if err != nil {
+		return nil, err
+	}
+	if block.Type != "PUBLIC KEY" {
+		return nil, fmt.Errorf("%w: %s", ErrWrongKeyType, block.Type)
+	}
+	return parsePublicKeyDER(block.Bytes)
+}
+
+// parsePublicKeyDER 解析 SubjectPublicKeyInfo DER 编码的 SM2 公钥
+func parsePublicKeyDER(der []byte) (*sm2.PublicKey, error) {
+	var key pkixPublicKey
+	if _, err := asn1.Unmarshal(der, &key); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrMalformedPEM, err)
+	}
+	if !isSM2Algorithm(key.Algo) {
+		return nil, fmt.Errorf("%w: 算法 %s", ErrWrongKeyType, key.Algo.Algorithm)
+	}
+	return newPublicKey(key.BitString.Bytes)
+}
+
+// newPublicKey 解析未压缩的公钥点 04 || X || Y
+func newPublicKey(point []byte) (*sm2.PublicKey, error) {
+	curve := sm2.P256Sm2()
+	x, y := elliptic.Unmarshal(curve, point)
+	if x == nil {
+		return nil, fmt.Errorf("%w: 公钥不是 SM2 曲线上的点", ErrMalformedPEM)
+	}
+	return &sm2.PublicKey{Curve: curve, X: x, Y: y}, nil
+}
+
+// PrivateKey 解析后的 SM2 私钥，加载一次后可重复使用，避免每次调用都解码 PEM、解密私钥，可并发使用
+type PrivateKey struct {
+	key *sm2.PrivateKey
+}
+
+// PublicKey 解析后的 SM2 公钥，可并发使用
+type PublicKey struct {
+	key *sm2.PublicKey
+}
+
+// ParsePrivateKeyPEM 从 PEM 加载私钥，ENCRYPTED PRIVATE KEY 使用 pwd 解密
+func ParsePrivateKeyPEM(data, pwd []byte) (*PrivateKey, error) {
+	key, err := parsePrivateKey(data, pwd)
+	if err != nil {
+		return nil, err
+	}
+	return &PrivateKey{key: key}, nil
+}
+
+// ParsePrivateKeyDER 从 PKCS#8 DER 加载私钥，pwd 为 nil 表示未加密，非 nil（包括空切片）表示加密私钥
+func ParsePrivateKeyDER(der, pwd []byte) (*PrivateKey, error) {
+	var (
+		key *sm2.PrivateKey
+		err error
+	)
+	if pwd == nil {
+		key, err = parseUnencryptedPrivateKey(der)
+	} else {
+		key, err = parseEncryptedPrivateKey(der, pwd)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &PrivateKey{key: key}, nil
+}
+
+// ParsePrivateKeyHex 从十六进制编码的私钥数值 D 加载私钥
+func ParsePrivateKeyHex(s string) (*PrivateKey, error) {
+	d, err := hex.DecodeString(s)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrMalformedPEM, err)
+	}
+	return NewPrivateKey(d)
+}
+
+// NewPrivateKey 从大端编码的私钥数值 D 加载私钥，最长 32 字节
+func NewPrivateKey(d []byte) (*PrivateKey, error) {
+	curve := sm2.P256Sm2()
+	k := new(big.Int).SetBytes(d)
+	// SM2 私钥取值范围为 [1, n-2]
+	if len(d) > 32 || k.Sign() == 0 || k.Cmp(new(big.Int).Sub(curve.Params().N, big.NewInt(1))) >= 0 {
+		return nil, fmt.Errorf("%w: 私钥数值无效", ErrMalformedPEM)
+	}
+	key := &sm2.PrivateKey{D: k}
+	key.Curve = curve
+	key.X, key.Y = curve.ScalarBaseMult(k.FillBytes(make([]byte, 32)))
+	return &PrivateKey{key: key}, nil
+}
+
+// Sign 使用默认用户 ID 签名，与 gsm2.Sign 的结果格式相同（ASN.1 编码），见 SignWithID
+func (k *PrivateKey) Sign(originalText []byte) ([]byte, error) {
+	return k.SignWithID(originalText, nil)
+}
+
+// Decrypt 解密，自动识别 ASN.1、C1C3C2、C1C2C3 格式，见 EncryptOptions
+func (k *PrivateKey) Decrypt(secretText []byte) ([]byte, error) {
+	return decryptSM2(k.key, secretText)
+}
+
+// Public 返回对应的公钥
+func (k *PrivateKey) Public() *PublicKey {
+	return &PublicKey{key: &k.key.PublicKey}
+}
+
+// ParsePublicKeyPEM 从 PEM 加载公钥
+func ParsePublicKeyPEM(data []byte) (*PublicKey, error) {
+	key, err := parsePublicKey(data)
+	if err != nil {
+		return nil, err
+	}
+	return &PublicKey{key: key}, nil
+}
+
+// ParsePublicKeyDER 从 SubjectPublicKeyInfo DER 加载公钥
+func ParsePublicKeyDER(der []byte) (*PublicKey, error) {
+	key, err := parsePublicKeyDER(der)
+	if err != nil {
+		return nil, err
+	}
+	return &PublicKey{key: key}, nil
+}
+
+// ParsePublicKeyHex 从十六进制编码的公钥点加载公钥，格式同 NewPublicKey
+func ParsePublicKeyHex(s string) (*PublicKey, error) {
+	b, err := hex.DecodeString(s)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrMalformedPEM, err)
+	}
+	return NewPublicKey(b)
+}
+
+// NewPublicKey 从公钥点加载公钥，支持 04 || X || Y（65 字节）和 X || Y（64 字节）
+func NewPublicKey(point []byte) (*PublicKey, error) {
+	if len(point) == 64 {
+		point = append([]byte{4}, point...)
+	}
+	key, err := newPublicKey(point)
+	if err != nil {
+		return nil, err
+	}
+	return &PublicKey{key: key}, nil
+}
+
+// Verify 使用默认用户 ID 验签，签名不匹配或格式错误时返回 false，见 VerifyWithID
+func (k *PublicKey) Verify(originalText, sign []byte) bool {
+	return k.VerifyWithID(originalText, sign, nil)
+}
+
+// Encrypt 加密，输出 ASN.1 编码的密文，与 gsm2.PublicKeyEncrypt 相同
+func (k *PublicKey) Encrypt(originalText []byte) ([]byte, error) {
+	return k.EncryptWithOptions(originalText, EncryptOptions{})
+}
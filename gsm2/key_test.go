@@ -0,0 +1,278 @@
+package gsm2
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/hex"
+	"encoding/pem"
+	"errors"
+	"math/big"
+	"strings"
+	"testing"
+
+	"github.com/tjfoc/gmsm/sm2"
+	smx509 "github.com/tjfoc/gmsm/x509"
+)
+
+func TestCorruptKeys(t *testing.T) {
+	pwd := []byte("123456")
+	private, public, err := GerenateSM2Key(pwd)
+	if err != nil {
+		t.Fatal(err)
+	}
+	plainPrivate, _, err := GerenateSM2Key(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ecKey, _ := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	ecDER, _ := x509.MarshalPKCS8PrivateKey(ecKey)
+	ecPrivate := pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: ecDER})
+	rsaKey, _ := rsa.GenerateKey(rand.Reader, 1024)
+	rsaDER, _ := x509.MarshalPKIXPublicKey(&rsaKey.PublicKey)
+	rsaPublic := pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: rsaDER})
+	ecPubDER, _ := x509.MarshalPKIXPublicKey(&ecKey.PublicKey)
+	ecPublic := pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: ecPubDER})
+
+	// corrupt 修改 PEM 中 DER 的第 i 个字节
+	corrupt := func(data []byte, i int) []byte {
+		block, _ := pem.Decode(data)
+		der := append([]byte(nil), block.Bytes...)
+		if i < 0 {
+			der = der[:len(der)+i]
+		} else {
+			der[i] ^= 0xff
+		}
+		return pem.EncodeToMemory(&pem.Block{Type: block.Type, Bytes: der})
+	}
+
+	privateCases := []struct {
+		name string
+		key  []byte
+		pwd  []byte
+		want error
+	}{
+		{"密码错误", private, []byte("654321"), ErrWrongPassword},
+		{"缺少密码", private, nil, ErrWrongPassword},
+		{"空数据", nil, pwd, ErrMalformedPEM},
+		{"不是 PEM", []byte("not a pem"), pwd, ErrMalformedPEM},
+		{"PEM 截断", private[:len(private)/2], pwd, ErrMalformedPEM},
+		{"加密私钥截断", corrupt(private, -5), pwd, ErrMalformedPEM},
+		{"加密私钥损坏", corrupt(private, 3), pwd, ErrMalformedPEM},
+		{"私钥损坏", corrupt(plainPrivate, 1), nil, ErrMalformedPEM},
+		{"ECDSA 私钥", ecPrivate, nil, ErrWrongKeyType},
+		{"公钥当作私钥", public, pwd, ErrWrongKeyType},
+	}
+	for _, c := range privateCases {
+		if _, err := Sign([]byte("123"), c.key, c.pwd); !errors.Is(err, c.want) {
+			t.Errorf("Sign %s: 应返回 %v, got %v", c.name, c.want, err)
+		}
+		if _, err := PrivateKeyDecrypt([]byte("123"), c.key, c.pwd); !errors.Is(err, c.want) {
+			t.Errorf("PrivateKeyDecrypt %s: 应返回 %v, got %v", c.name, c.want, err)
+		}
+	}
+
+	sign, err := Sign([]byte("123"), private, pwd)
+	if err != nil {
+		t.Fatal(err)
+	}
+	publicCases := []struct {
+		name string
+		key  []byte
+		want error
+	}{
+		{"不是 PEM", []byte(strings.Repeat("-", 20)), ErrMalformedPEM},
+		{"公钥损坏", corrupt(public, 2), ErrMalformedPEM},
+		{"公钥不在曲线上", corrupt(public, -1), ErrMalformedPEM},
+		{"RSA 公钥", rsaPublic, ErrWrongKeyType},
+		{"ECDSA 公钥", ecPublic, ErrWrongKeyType},
+		{"私钥当作公钥", private, ErrWrongKeyType},
+	}
+	for _, c := range publicCases {
+		if ok, err := Verify([]byte("123"), sign, c.key); ok || !errors.Is(err, c.want) {
+			t.Errorf("Verify %s: 应返回 %v, got %v %v", c.name, c.want, ok, err)
+		}
+		if _, err := PublicKeyEncrypt([]byte("123"), c.key); !errors.Is(err, c.want) {
+			t.Errorf("PublicKeyEncrypt %s: 应返回 %v, got %v", c.name, c.want, err)
+		}
+	}
+
+	// 签名不匹配不是错误
+	if ok, err := Verify([]byte("1234"), sign, public); ok || err != nil {
+		t.Errorf("签名不匹配应返回 false, nil, got %v %v", ok, err)
+	}
+	if ok, err := Verify([]byte("123"), []byte("bad"), public); ok || err != nil {
+		t.Errorf("签名格式错误应返回 false, nil, got %v %v", ok, err)
+	}
+	// 未加密私钥忽略密码
+	if _, err := Sign([]byte("123"), plainPrivate, pwd); err != nil {
+		t.Errorf("未加密私钥签名失败: %v", err)
+	}
+}
+
+func TestParsedKeys(t *testing.T) {
+	pwd := []byte("123456")
+	private, public, err := GerenateSM2Key(pwd)
+	if err != nil {
+		t.Fatal(err)
+	}
+	priv, err := ParsePrivateKeyPEM(private, pwd)
+	if err != nil {
+		t.Fatal(err)
+	}
+	pub, err := ParsePublicKeyPEM(public)
+	if err != nil {
+		t.Fatal(err)
+	}
+	text := []byte("123")
+	sign, err := priv.Sign(text)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// 与 PEM 函数互通
+	if ok, err := Verify(text, sign, public); !ok || err != nil {
+		t.Errorf("Verify 验证 PrivateKey.Sign 的签名失败: %v %v", ok, err)
+	}
+	if sign, _ = Sign(text, private, pwd); !pub.Verify(text, sign) || !priv.Public().Verify(text, sign) {
+		t.Error("PublicKey.Verify 验证 Sign 的签名失败")
+	}
+	st, err := PublicKeyEncrypt(text, public)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if ot, err := priv.Decrypt(st); err != nil || string(ot) != "123" {
+		t.Errorf("PrivateKey.Decrypt 解密失败 %q %v", ot, err)
+	}
+	if st, err = pub.Encrypt(text); err != nil {
+		t.Fatal(err)
+	}
+	if ot, err := PrivateKeyDecrypt(st, private, pwd); err != nil || string(ot) != "123" {
+		t.Errorf("PrivateKeyDecrypt 解密失败 %q %v", ot, err)
+	}
+
+	// DER、hex 和原始字节加载得到相同的密钥
+	block, _ := pem.Decode(private)
+	fromDER, err := ParsePrivateKeyDER(block.Bytes, pwd)
+	if err != nil {
+		t.Fatal(err)
+	}
+	fromHex, err := ParsePrivateKeyHex(hex.EncodeToString(priv.key.D.Bytes()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, k := range []*PrivateKey{fromDER, fromHex} {
+		if k.key.D.Cmp(priv.key.D) != 0 || k.key.X.Cmp(priv.key.X) != 0 {
+			t.Error("加载的私钥不一致")
+		}
+	}
+	block, _ = pem.Decode(public)
+	pubFromDER, err := ParsePublicKeyDER(block.Bytes)
+	if err != nil {
+		t.Fatal(err)
+	}
+	point := elliptic.Marshal(pub.key.Curve, pub.key.X, pub.key.Y)
+	pubFromHex, err := ParsePublicKeyHex(hex.EncodeToString(point))
+	if err != nil {
+		t.Fatal(err)
+	}
+	pubFromXY, err := NewPublicKey(point[1:])
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, k := range []*PublicKey{pubFromDER, pubFromHex, pubFromXY} {
+		if !k.Verify(text, sign) {
+			t.Error("加载的公钥验签失败")
+		}
+	}
+}
+
+func TestParsedKeysInvalid(t *testing.T) {
+	n := sm2.P256Sm2().Params().N
+	for _, d := range [][]byte{make([]byte, 32), n.Bytes(), new(big.Int).Sub(n, big.NewInt(1)).Bytes(), make([]byte, 33)} {
+		if _, err := NewPrivateKey(d); !errors.Is(err, ErrMalformedPEM) {
+			t.Errorf("私钥 %x 应返回 ErrMalformedPEM, got %v", d, err)
+		}
+	}
+	if _, err := ParsePrivateKeyHex("xyz"); !errors.Is(err, ErrMalformedPEM) {
+		t.Errorf("hex 错误应返回 ErrMalformedPEM, got %v", err)
+	}
+	if _, err := NewPrivateKey([]byte{1}); err != nil {
+		t.Errorf("较短的私钥数值应允许: %v", err)
+	}
+	point := make([]byte, 65)
+	point[0] = 4
+	if _, err := NewPublicKey(point); !errors.Is(err, ErrMalformedPEM) {
+		t.Errorf("不在曲线上的点应返回 ErrMalformedPEM, got %v", err)
+	}
+	if _, err := ParsePublicKeyHex("04"); !errors.Is(err, ErrMalformedPEM) {
+		t.Errorf("长度错误应返回 ErrMalformedPEM, got %v", err)
+	}
+	if _, err := ParsePrivateKeyDER([]byte{0x30, 0x00}, nil); !errors.Is(err, ErrMalformedPEM) {
+		t.Errorf("DER 错误应返回 ErrMalformedPEM, got %v", err)
+	}
+}
+
+// 对比每次解析 PEM（含私钥解密）和复用解析后密钥的签名、验签耗时
+func BenchmarkSignPEM(b *testing.B) {
+	pwd := []byte("123456")
+	private, _, _ := GerenateSM2Key(pwd)
+	text := []byte("benchmark message")
+	for i := 0; i < b.N; i++ {
+		if _, err := Sign(text, private, pwd); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func BenchmarkPrivateKeySign(b *testing.B) {
+	pwd := []byte("123456")
+	private, _, _ := GerenateSM2Key(pwd)
+	priv, _ := ParsePrivateKeyPEM(private, pwd)
+	text := []byte("benchmark message")
+	for i := 0; i < b.N; i++ {
+		if _, err := priv.Sign(text); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func BenchmarkVerifyPEM(b *testing.B) {
+	private, public, _ := GerenateSM2Key(nil)
+	text := []byte("benchmark message")
+	sign, _ := Sign(text, private, nil)
+	for i := 0; i < b.N; i++ {
+		if ok, err := Verify(text, sign, public); !ok || err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func BenchmarkPublicKeyVerify(b *testing.B) {
+	private, public, _ := GerenateSM2Key(nil)
+	text := []byte("benchmark message")
+	sign, _ := Sign(text, private, nil)
+	pub, _ := ParsePublicKeyPEM(public)
+	for i := 0; i < b.N; i++ {
+		if !pub.Verify(text, sign) {
+			b.Fatal("验签失败")
+		}
+	}
+}
+
+// 密码错误只能通过错误文本识别，文本随 gmsm 版本变化时此测试失败
+func TestIncorrectPasswordText(t *testing.T) {
+	priv, err := sm2.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	der, err := smx509.MarshalSm2PrivateKey(priv, []byte("123456"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = smx509.ParsePKCS8EcryptedPrivateKey(der, []byte("654321"))
+	if err == nil || err.Error() != errIncorrectPassword {
+		t.Fatalf("gmsm 密码错误的文本已变化: %v", err)
+	}
+}
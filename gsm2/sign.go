@@ -0,0 +1,82 @@
+package gsm2
+
+import (
+	"crypto/rand"
+	"encoding/asn1"
+	"errors"
+	"io"
+	"math/big"
+
+	"github.com/tjfoc/gmsm/sm2"
+)
+
+// SM2 数字签名（GM/T 0003.2、GM/T 0009）：
+//  1、签名和验签由 tjfoc/gmsm 的 Sm2Sign/Sm2Verify 完成，e = SM3(Z || M)，Z 由用户 ID 和公钥计算
+//  2、签名按 GM/T 0009 编码为 ASN.1 SEQUENCE { r INTEGER, s INTEGER }
+// 用户 ID 为空时使用 GM/T 0009 规定的默认 ID "1234567812345678"，签名方和验签方必须使用相同的 ID
+
+// DefaultUserID GM/T 0009 默认用户 ID
+var DefaultUserID = []byte("1234567812345678")
+
+// maxUserIDLen ENTL 为 2 字节比特长度，ID 最长 8191 字节
+const maxUserIDLen = 0xffff / 8
+
+// ErrUserIDTooLong 用户 ID 过长
+var ErrUserIDTooLong = errors.New("gsm2: 用户 ID 不能超过 8191 字节")
+
+// sm2Signature GM/T 0009 签名值
+type sm2Signature struct {
+	R, S *big.Int
+}
+
+// signSM2 调用 tjfoc/gmsm 签名，random 用于生成随机数 k，输出 ASN.1 编码
+func signSM2(random io.Reader, priv *sm2.PrivateKey, msg, uid []byte) ([]byte, error) {
+	if len(uid) > maxUserIDLen {
+		return nil, ErrUserIDTooLong
+	}
+	r, s, err := sm2.Sm2Sign(priv, msg, uid, random)
+	if err != nil {
+		return nil, err
+	}
+	return asn1.Marshal(sm2Signature{r, s})
+}
+
+// verifySM2 验证 ASN.1 编码的签名，签名后有多余数据时验签失败
+func verifySM2(pub *sm2.PublicKey, msg, sign, uid []byte) bool {
+	var sig sm2Signature
+	if rest, err := asn1.Unmarshal(sign, &sig); err != nil || len(rest) != 0 {
+		return false
+	}
+	if len(uid) > maxUserIDLen {
+		return false
+	}
+	return sm2.Sm2Verify(pub, msg, uid, sig.R, sig.S)
+}
+
+// SignWithID 使用用户 ID 签名，uid 为空时使用 DefaultUserID
+func (k *PrivateKey) SignWithID(originalText, uid []byte) ([]byte, error) {
+	return signSM2(rand.Reader, k.key, originalText, uid)
+}
+
+// VerifyWithID 使用用户 ID 验签，uid 必须与签名时一致，为空时使用 DefaultUserID
+func (k *PublicKey) VerifyWithID(originalText, sign, uid []byte) bool {
+	return verifySM2(k.key, originalText, sign, uid)
+}
+
+// SignWithID 使用 PEM 私钥和用户 ID 签名，uid 为空时使用 DefaultUserID
+func SignWithID(originalText, uid, privateKey, pwd []byte) ([]byte, error) {
+	priv, err := ParsePrivateKeyPEM(privateKey, pwd)
+	if err != nil {
+		return nil, err
+	}
+	return priv.SignWithID(originalText, uid)
+}
+
+// VerifyWithID 使用 PEM 公钥和用户 ID 验签，签名不匹配返回 false 和 nil
+func VerifyWithID(originalText, sign, uid, publicKey []byte) (bool, error) {
+	pub, err := ParsePublicKeyPEM(publicKey)
+	if err != nil {
+		return false, err
+	}
+	return pub.VerifyWithID(originalText, sign, uid), nil
+}
@@ -0,0 +1,120 @@
+package gsm2
+
+import (
+	"bytes"
+	"crypto/rand"
+	"encoding/asn1"
+	"errors"
+	"fmt"
+	"math/big"
+	"testing"
+
+	"github.com/tjfoc/gmsm/sm2"
+)
+
+// GM/T 0003.5 附录 A 推荐曲线上的签名示例，用户 ID 为默认 ID
+func TestSignVector(t *testing.T) {
+	priv, err := ParsePrivateKeyHex("3945208f7b2144b13f36e38ac6d39f95889393692860b51a42fb81ef4df7c5b8")
+	if err != nil {
+		t.Fatal(err)
+	}
+	pub := priv.Public()
+	hex := func(v any) string { return fmt.Sprintf("%064x", v) }
+	if hex(pub.key.X) != "09f9df311e5421a150dd7d161e4bc5c672179fad1833fc076bb08ff356f35020" ||
+		hex(pub.key.Y) != "ccea490ce26775a52dc6ea718cc1aa600aed05fbf35e084a6632f6072da9ad13" {
+		t.Fatalf("公钥错误 %x %x", pub.key.X, pub.key.Y)
+	}
+	z, err := sm2.ZA(pub.key, DefaultUserID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if hex(z) != "b2e14c5c79c6df5b85f4fe7ed8db7a262b9da7e07ccb0ea9f4747b8ccda8a4f3" {
+		t.Errorf("Z 错误 %x", z)
+	}
+	msg := []byte("message digest")
+	e, _ := pub.key.Sm3Digest(msg, nil)
+	if hex(e) != "f0b43e94ba45accaace692ed534382eb17e6ab5a19ce7b31f4486fdfc0d28640" {
+		t.Errorf("e 错误 %x", e)
+	}
+	// tjfoc/gmsm 读取 40 字节随机数 b，k = b mod (n-1) + 1，传入 k-1 即可固定 k
+	k, _ := new(big.Int).SetString("59276e27d506861a16680f3ad9c02dccef3cc1fa3cdbe4ce6d54b80deac1bc21", 16)
+	random := bytes.NewReader(new(big.Int).Sub(k, big.NewInt(1)).FillBytes(make([]byte, 40)))
+	sign, err := signSM2(random, priv.key, msg, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var sig sm2Signature
+	if _, err := asn1.Unmarshal(sign, &sig); err != nil {
+		t.Fatal(err)
+	}
+	if hex(sig.R) != "f5a03b0648d2c4630eeac513e1bb81a15944da3827d5b74143ac7eaceee720b3" ||
+		hex(sig.S) != "b1b6aa29df212fd8763182bc0d421ca1bb9038fd1f7f42d4840b69c485bbc1aa" {
+		t.Errorf("签名错误 r=%x s=%x", sig.R, sig.S)
+	}
+	if !pub.VerifyWithID(msg, sign, DefaultUserID) || !pub.Verify(msg, sign) {
+		t.Error("验证示例签名失败")
+	}
+	if pub.VerifyWithID(msg, sign, []byte("ALICE123@YAHOO.COM")) {
+		t.Error("用户 ID 不一致应验签失败")
+	}
+}
+
+func TestSignWithID(t *testing.T) {
+	private, public, err := GerenateSM2Key(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	msg := []byte("123")
+	uid := []byte("ALICE123@YAHOO.COM")
+	sign, err := SignWithID(msg, uid, private, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if ok, err := VerifyWithID(msg, sign, uid, public); !ok || err != nil {
+		t.Errorf("验签失败 %v %v", ok, err)
+	}
+	if ok, _ := VerifyWithID(msg, sign, nil, public); ok {
+		t.Error("使用默认 ID 验证自定义 ID 的签名应失败")
+	}
+	if ok, _ := VerifyWithID([]byte("1234"), sign, uid, public); ok {
+		t.Error("原文不一致应验签失败")
+	}
+
+	// 与 tjfoc/gmsm 的实现互通
+	priv, _ := ParsePrivateKeyPEM(private, nil)
+	var sig sm2Signature
+	if _, err := asn1.Unmarshal(sign, &sig); err != nil {
+		t.Fatal(err)
+	}
+	if !sm2.Sm2Verify(&priv.key.PublicKey, msg, uid, sig.R, sig.S) {
+		t.Error("tjfoc/gmsm 验证自定义 ID 签名失败")
+	}
+	r, s, err := sm2.Sm2Sign(priv.key, msg, uid, rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	other, _ := asn1.Marshal(sm2Signature{r, s})
+	if !priv.Public().VerifyWithID(msg, other, uid) {
+		t.Error("验证 tjfoc/gmsm 的签名失败")
+	}
+
+	// 尾部多余数据和超出范围的签名值
+	if priv.Public().VerifyWithID(msg, append(append([]byte(nil), sign...), 0), uid) {
+		t.Error("签名后有多余数据应验签失败")
+	}
+	n := sm2.P256Sm2().Params().N
+	for _, bad := range []sm2Signature{{big.NewInt(0), sig.S}, {sig.R, n}, {new(big.Int).Neg(sig.R), sig.S}} {
+		b, _ := asn1.Marshal(bad)
+		if priv.Public().VerifyWithID(msg, b, uid) {
+			t.Errorf("签名值超出范围应验签失败: %v", bad)
+		}
+	}
+
+	long := bytes.Repeat([]byte("a"), maxUserIDLen+1)
+	if _, err := priv.SignWithID(msg, long); !errors.Is(err, ErrUserIDTooLong) {
+		t.Errorf("用户 ID 过长应返回 ErrUserIDTooLong, got %v", err)
+	}
+	if _, err := priv.SignWithID(msg, long[:maxUserIDLen]); err != nil {
+		t.Errorf("8191 字节的用户 ID 应允许: %v", err)
+	}
+}
@@ -1,7 +1,6 @@
 package gsm2
 
 import (
-	"crypto"
 	"crypto/rand"
 	"github.com/tjfoc/gmsm/sm2"
 	"github.com/tjfoc/gmsm/x509"
@@ -36,27 +35,27 @@ func GerenateSM2Key(pwd []byte) (private, public []byte, err error) {
 // PublicKeyEncrypt 公钥加密 originalText 加密原文 publicKey 公钥  return  密文
 func PublicKeyEncrypt(originalText []byte, publicKey []byte) ([]byte, error) {
 	//1.将pem格式公钥解码并反序列化
-	publicKeyFromPem, err := x509.ReadPublicKeyFromPem(publicKey)
+	publicKeyFromPem, err := ParsePublicKeyPEM(publicKey)
 	if err != nil {
 		return nil, err
 	}
 	//2.加密
-	secretText, err := publicKeyFromPem.EncryptAsn1(originalText, rand.Reader)
+	secretText, err := publicKeyFromPem.Encrypt(originalText)
 	if err != nil {
 		return nil, err
 	}
 	return secretText, err
 }
 
-// PrivateKeyDecrypt 私钥解密
+// PrivateKeyDecrypt 私钥解密，自动识别 ASN.1、C1C3C2、C1C2C3 格式
 func PrivateKeyDecrypt(secretText []byte, privateKey []byte, pwd []byte) ([]byte, error) {
 	//1.将pem格式私钥文件解码并反序列话
-	privateKeyFromPem, err := x509.ReadPrivateKeyFromPem(privateKey, pwd)
+	privateKeyFromPem, err := ParsePrivateKeyPEM(privateKey, pwd)
 	if err != nil {
 		return nil, err
 	}
 	//2.解密
-	originalText, err := privateKeyFromPem.DecryptAsn1(secretText)
+	originalText, err := privateKeyFromPem.Decrypt(secretText)
 	if err != nil {
 		return nil, err
 	}
@@ -64,28 +63,25 @@ func PrivateKeyDecrypt(secretText []byte, privateKey []byte, pwd []byte) ([]byte
 }
 
 // Sign 签名 originalText 签名原文 privateKey 私钥
-func Sign(originalText []byte, privateKey []byte, pwd []byte) []byte {
+// 私钥无法解析时返回 ErrMalformedPEM、ErrWrongPassword 或 ErrWrongKeyType
+func Sign(originalText []byte, privateKey []byte, pwd []byte) ([]byte, error) {
 	//1.将pem格式私钥文件解码并反序列话
-	privateKeyFromPem, err := x509.ReadPrivateKeyFromPem(privateKey, pwd)
+	privateKeyFromPem, err := ParsePrivateKeyPEM(privateKey, pwd)
 	if err != nil {
-		panic(err)
+		return nil, err
 	}
 	//2.签名
-	sign, err := privateKeyFromPem.Sign(rand.Reader, originalText, crypto.SHA256)
-	if err != nil {
-		panic(err)
-	}
-	return sign
+	return privateKeyFromPem.Sign(originalText)
 }
 
-// Verify 验签  originalText 密文 sign 摘要 publicKey 公钥
-func Verify(secretText, sign, publicKey []byte) bool {
+// Verify 验签  originalText 签名原文 sign 签名 publicKey 公钥
+// 签名不匹配返回 false 和 nil，公钥无法解析时返回错误
+func Verify(originalText, sign, publicKey []byte) (bool, error) {
 	//1.将pem格式公钥解码并反序列化
-	publicKeyFromPem, err := x509.ReadPublicKeyFromPem(publicKey)
+	publicKeyFromPem, err := ParsePublicKeyPEM(publicKey)
 	if err != nil {
-		panic(err)
+		return false, err
 	}
-	//3.验签
-	verify := publicKeyFromPem.Verify(secretText, sign)
-	return verify
+	//2.验签
+	return publicKeyFromPem.Verify(originalText, sign), nil
 }
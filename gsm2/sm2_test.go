@@ -13,7 +13,11 @@ func TestSm2(t *testing.T) {
 		t.Error("生成随机密钥失败", err)
 		return
 	}
-	sign := Sign(text, private, pwd)
+	sign, err := Sign(text, private, pwd)
+	if err != nil {
+		t.Error("签名失败", err)
+		return
+	}
 	st, err := PublicKeyEncrypt(text, public)
 	if err != nil {
 		t.Error(err)
@@ -26,7 +30,11 @@ func TestSm2(t *testing.T) {
 		return
 	}
 	log.Println(string(ot))
-	b := Verify(ot, sign, public)
+	b, err := Verify(ot, sign, public)
+	if err != nil {
+		t.Error(err)
+		return
+	}
 	if !b {
 		t.Error("验签名失败")
 	}